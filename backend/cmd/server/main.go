@@ -3,14 +3,24 @@ package main
 // Updated: 2025-09-14 - API compatibility fixes for frontend integration
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"bech-do-backend/internal/api/handlers"
 	"bech-do-backend/internal/api/middleware"
 	"bech-do-backend/internal/api/routes"
 	"bech-do-backend/internal/config"
 	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/notifications"
+	"bech-do-backend/internal/services/realtime"
+	"bech-do-backend/internal/worker"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +30,10 @@ func main() {
 	cfg := config.LoadConfig()
 	log.Printf("Starting Bech-Do API server in %s mode", cfg.Environment)
 
+	// Stop gracefully on SIGINT and SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize database
 	repository.InitDatabase()
 	log.Println("Database connected and migrated successfully")
@@ -32,11 +46,59 @@ func main() {
 	// Create Gin router
 	router := gin.New()
 
+	// Only believe X-Forwarded-For from our own proxies, so clients cannot
+	// choose the IP that is throttled and written to the audit log
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
 	// Add middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORSMiddleware())
 
+	// Start the realtime hub
+	hub := realtime.NewHub(realtime.NewPubSub(cfg, repository.DB))
+	go func() {
+		if err := hub.Run(ctx); err != nil {
+			log.Printf("Realtime hub stopped: %v", err)
+		}
+	}()
+	realtime.SetDefault(hub)
+
+	// Push domain events to the users they concern
+	events.Subscribe(func(ctx context.Context, event events.Event) {
+		realtime.Publish(event.Type, event.Data, event.UserIDs...)
+	})
+
+	// Queue the notifications of domain events
+	notifier := notifications.NewService(cfg, repository.DB)
+	events.Subscribe(notifier.Handle)
+
+	var background sync.WaitGroup
+
+	// Batch product views into jobs
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		handlers.RunViewFlusher(ctx, 10*time.Second)
+	}()
+
+	// Run background jobs here unless a separate worker does
+	if cfg.JobsInProcess {
+		runner, err := worker.NewRunner(cfg, repository.DB)
+		if err != nil {
+			log.Fatal("Failed to configure job runner:", err)
+		}
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			if err := runner.Run(ctx); err != nil {
+				log.Printf("Job runner stopped: %v", err)
+			}
+		}()
+	}
+
 	// Setup routes
 	routes.SetupRoutes(router)
 
@@ -54,7 +116,21 @@ func main() {
 	log.Printf("API base URL: http://%s:%s/api/v1", cfg.ServerHost, cfg.ServerPort)
 
 	// Start server
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatal("Server failed to start:", err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed to start:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
 	}
+
+	background.Wait()
+	log.Println("Server stopped")
 }
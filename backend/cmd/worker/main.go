@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/notifications"
+	"bech-do-backend/internal/services/realtime"
+	"bech-do-backend/internal/worker"
+)
+
+// The worker runs background jobs without serving the API. Run the server
+// with JOBS_IN_PROCESS=false when using it.
+func main() {
+	// Load configuration
+	cfg := config.LoadConfig()
+	log.Printf("Starting Bech-Do worker in %s mode", cfg.Environment)
+
+	// Stop gracefully on SIGINT and SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize database
+	repository.InitDatabase()
+	log.Println("Database connected and migrated successfully")
+
+	// Realtime events only reach the servers' clients through Postgres; the
+	// in-process driver has no clients here
+	if cfg.RealtimeDriver == "postgres" {
+		realtime.SetDefault(realtime.NewHub(realtime.NewPubSub(cfg, repository.DB)))
+		events.Subscribe(func(ctx context.Context, event events.Event) {
+			realtime.Publish(event.Type, event.Data, event.UserIDs...)
+		})
+	}
+
+	// Queue the notifications of domain events
+	notifier := notifications.NewService(cfg, repository.DB)
+	events.Subscribe(notifier.Handle)
+
+	runner, err := worker.NewRunner(cfg, repository.DB)
+	if err != nil {
+		log.Fatal("Failed to configure job runner:", err)
+	}
+	if err := runner.Run(ctx); err != nil {
+		log.Fatal("Job runner failed:", err)
+	}
+}
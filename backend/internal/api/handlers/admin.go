@@ -0,0 +1,596 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/audit"
+	"bech-do-backend/internal/services/lifecycle"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxDashboardBuckets bounds the length of the dashboard time series.
+const maxDashboardBuckets = 366
+
+type AdminHandler struct{}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type ChangeRoleRequest struct {
+	Role models.UserRole `json:"role" binding:"required"`
+}
+
+// adminProduct is a product as admins see it, including whether and when
+// it was deleted.
+type adminProduct struct {
+	models.Product
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// dashboardPoint is one bucket of a dashboard time series.
+type dashboardPoint struct {
+	Date  time.Time `json:"date"`
+	Count int64     `json:"count"`
+}
+
+// userSortColumns whitelists the values accepted by the sort parameter of
+// GetUsers.
+var userSortColumns = map[string]string{
+	"created_at": "users.created_at",
+	"email":      "users.email",
+	"rating":     "users.rating",
+}
+
+// dashboardSeries are the time series on the dashboard, each counting the
+// rows of a table by one of its timestamps.
+var dashboardSeries = []struct {
+	name, table, column string
+}{
+	{"signups", "users", "created_at"},
+	{"listings", "products", "listed_at"},
+	{"sales", "products", "sold_at"},
+	{"reports", "reports", "created_at"},
+}
+
+// GetUsers lists users with search and filters, including suspended ones.
+func (h *AdminHandler) GetUsers(c *gin.Context) {
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	search := strings.TrimSpace(c.Query("search"))
+	role := c.Query("role")
+	active := c.Query("active")
+	verified := c.Query("verified")
+	sortBy := c.DefaultQuery("sort", "created_at")
+	order := c.DefaultQuery("order", "desc")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.User{})
+
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("users.email ILIKE ? OR users.username ILIKE ? OR users.first_name ILIKE ? OR users.last_name ILIKE ?",
+			pattern, pattern, pattern, pattern)
+	}
+
+	if role != "" {
+		query = query.Where("users.role = ?", role)
+	}
+
+	if active != "" {
+		query = query.Where("users.is_active = ?", active == "true")
+	}
+
+	if verified != "" {
+		query = query.Where("users.is_verified = ?", verified == "true")
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	column, ok := userSortColumns[sortBy]
+	if !ok {
+		column = userSortColumns["created_at"]
+	}
+	direction := "DESC"
+	if strings.EqualFold(order, "asc") {
+		direction = "ASC"
+	}
+
+	var users []models.User
+	result := query.
+		Order(column + " " + direction + ", users.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&users)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":      users,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// GetUser returns a user with a summary of their activity.
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, ok := loadAdminUser(c)
+	if !ok {
+		return
+	}
+
+	var listings []struct {
+		Status models.ProductStatus
+		Count  int64
+	}
+	err := repository.DB.Model(&models.Product{}).
+		Select("status, COUNT(*) AS count").
+		Where("user_id = ?", user.ID).
+		Group("status").
+		Scan(&listings).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	listingCounts := make(map[models.ProductStatus]int64, len(listings))
+	for _, l := range listings {
+		listingCounts[l.Status] = l.Count
+	}
+
+	var sales, purchases, reportsAgainst, activeSessions int64
+	repository.DB.Model(&models.Transaction{}).Where("seller_id = ?", user.ID).Count(&sales)
+	repository.DB.Model(&models.Transaction{}).Where("buyer_id = ?", user.ID).Count(&purchases)
+	repository.DB.Model(&models.Report{}).
+		Joins("JOIN products ON products.id = reports.product_id").
+		Where("products.user_id = ?", user.ID).
+		Count(&reportsAgainst)
+	repository.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", user.ID, time.Now()).
+		Count(&activeSessions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+		"stats": gin.H{
+			"listings":       listingCounts,
+			"sales":          sales,
+			"purchases":      purchases,
+			"reportsAgainst": reportsAgainst,
+			"activeSessions": activeSessions,
+		},
+	})
+}
+
+// SuspendUser deactivates a user and signs them out everywhere.
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := loadAdminUser(c)
+	if !ok {
+		return
+	}
+
+	if user.ID == adminID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot suspend yourself"})
+		return
+	}
+
+	if !user.IsActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already suspended"})
+		return
+	}
+
+	now := time.Now()
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"is_active":         false,
+			"suspended_at":      now,
+			"suspension_reason": req.Reason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+
+		entry := auditEntry(c, models.AuditUserSuspend, models.AuditTargetUser, user.ID)
+		entry.Changes = map[string]interface{}{
+			"isActive": gin.H{"before": true, "after": false},
+			"reason":   req.Reason,
+		}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+
+	user.IsActive = false
+	user.SuspendedAt = &now
+	user.SuspensionReason = req.Reason
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// ReactivateUser lifts a suspension.
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	user, ok := loadAdminUser(c)
+	if !ok {
+		return
+	}
+
+	if user.IsActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is not suspended"})
+		return
+	}
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"is_active":         true,
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		entry := auditEntry(c, models.AuditUserReactivate, models.AuditTargetUser, user.ID)
+		entry.Changes = map[string]interface{}{
+			"isActive":         gin.H{"before": false, "after": true},
+			"suspensionReason": gin.H{"before": user.SuspensionReason, "after": ""},
+		}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reactivate user"})
+		return
+	}
+
+	user.IsActive = true
+	user.SuspendedAt = nil
+	user.SuspensionReason = ""
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// ChangeRole sets a user's role. The user's sessions are revoked so the new
+// role applies at once.
+func (h *AdminHandler) ChangeRole(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	user, ok := loadAdminUser(c)
+	if !ok {
+		return
+	}
+
+	// Admins cannot lock themselves out
+	if user.ID == adminID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	if user.Role == req.Role {
+		c.JSON(http.StatusOK, gin.H{"user": user})
+		return
+	}
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("role", req.Role).Error; err != nil {
+			return err
+		}
+		if err := revokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+
+		entry := auditEntry(c, models.AuditUserRoleChange, models.AuditTargetUser, user.ID)
+		entry.Changes = map[string]interface{}{
+			"role": gin.H{"before": user.Role, "after": req.Role},
+		}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+
+	user.Role = req.Role
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// GetProducts lists products in any status with search and filters. Pass
+// deleted=include to add deleted products, or deleted=only to see just
+// those.
+func (h *AdminHandler) GetProducts(c *gin.Context) {
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	search := strings.TrimSpace(c.Query("search"))
+	status := c.Query("status")
+	category := c.Query("category")
+	sellerID := c.Query("user_id")
+	deleted := c.Query("deleted")
+	sortBy := c.DefaultQuery("sort", "created_at")
+	order := c.DefaultQuery("order", "desc")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Product{})
+
+	switch deleted {
+	case "":
+	case "include":
+		query = query.Unscoped()
+	case "only":
+		query = query.Unscoped().Where("products.deleted_at IS NOT NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deleted must be include or only"})
+		return
+	}
+
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("products.title ILIKE ? OR products.description ILIKE ?", pattern, pattern)
+	}
+
+	if status != "" {
+		query = query.Where("products.status = ?", status)
+	}
+
+	if category != "" {
+		query = query.Where("products.category_id = ?", category)
+	}
+
+	if sellerID != "" {
+		query = query.Where("products.user_id = ?", sellerID)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var products []models.Product
+	result := query.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Category", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order(productOrder(sortBy, order, models.ProductFilters{})).
+		Limit(limit).
+		Offset(offset).
+		Find(&products)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		return
+	}
+
+	productRefs := make([]*models.Product, len(products))
+	for i := range products {
+		productRefs[i] = &products[i]
+	}
+	if err := attachSellers(productRefs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sellers"})
+		return
+	}
+
+	items := make([]adminProduct, len(products))
+	for i, product := range products {
+		items[i] = adminProduct{Product: product}
+		if product.DeletedAt.Valid {
+			items[i].DeletedAt = &product.DeletedAt.Time
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":   items,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// RestoreProduct brings back a deleted or removed product as hidden, for the
+// seller to relist. Deleted listings that were on the market are hidden too.
+func (h *AdminHandler) RestoreProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var product models.Product
+	result := repository.DB.Unscoped().First(&product, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	wasDeleted := product.DeletedAt.Valid
+	var action lifecycle.Action
+	switch {
+	case product.Status == models.ProductStatusRemoved:
+		action = lifecycle.ActionRestore
+	case wasDeleted && product.Status.IsListed():
+		action = lifecycle.ActionHide
+	case !wasDeleted:
+		c.JSON(http.StatusConflict, gin.H{"error": "Only deleted or removed products can be restored"})
+		return
+	}
+
+	before := product.Status
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if wasDeleted {
+			if err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", product.ID).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		if action != "" {
+			if err := lifecycle.Apply(tx, &product, action, lifecycle.Options{}); err != nil {
+				return err
+			}
+		}
+
+		entry := auditEntry(c, models.AuditProductRestore, models.AuditTargetProduct, product.ID)
+		entry.Changes = map[string]interface{}{
+			"status": gin.H{"before": before, "after": product.Status},
+		}
+		if wasDeleted {
+			entry.Changes["deleted"] = gin.H{"before": true, "after": false}
+		}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		respondLifecycleError(c, err, product.Status, action)
+		return
+	}
+
+	// Load updated product with relationships
+	repository.DB.Preload("User").Preload("Category").First(&product, id)
+	attachSellers(&product)
+
+	c.JSON(http.StatusOK, gin.H{"product": product})
+}
+
+// GetDashboard returns marketplace totals and daily or weekly counts of
+// signups, new listings, sales and reports over the last `days` days.
+func (h *AdminHandler) GetDashboard(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > maxDashboardBuckets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+		return
+	}
+	interval := c.DefaultQuery("interval", "day")
+	step := 24 * time.Hour
+	switch interval {
+	case "day":
+	case "week":
+		step = 7 * 24 * time.Hour
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be day or week"})
+		return
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := today.AddDate(0, 0, 1-days)
+	if interval == "week" {
+		// Weeks start on Monday, like date_trunc
+		from = from.AddDate(0, 0, -(int(from.Weekday())+6)%7)
+	}
+
+	series := make(map[string][]dashboardPoint, len(dashboardSeries))
+	for _, s := range dashboardSeries {
+		var rows []struct {
+			Bucket time.Time
+			Count  int64
+		}
+		// Table and column names come from dashboardSeries, not the request
+		err := repository.DB.Raw(`SELECT date_trunc(?, `+s.column+` AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS count
+			FROM `+s.table+`
+			WHERE `+s.column+` >= ?
+			GROUP BY bucket`, interval, from).
+			Scan(&rows).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dashboard"})
+			return
+		}
+
+		counts := make(map[int64]int64, len(rows))
+		for _, row := range rows {
+			counts[row.Bucket.Unix()] = row.Count
+		}
+		var points []dashboardPoint
+		for bucket := from; !bucket.After(today); bucket = bucket.Add(step) {
+			points = append(points, dashboardPoint{Date: bucket, Count: counts[bucket.Unix()]})
+		}
+		series[s.name] = points
+	}
+
+	var totalUsers, suspendedUsers, liveListings, pendingReview, openReports int64
+	repository.DB.Model(&models.User{}).Count(&totalUsers)
+	repository.DB.Model(&models.User{}).Where("is_active = ?", false).Count(&suspendedUsers)
+	repository.DB.Model(&models.Product{}).Where("status = ?", models.ProductStatusAvailable).Count(&liveListings)
+	repository.DB.Model(&models.Product{}).Where("status = ?", models.ProductStatusPendingReview).Count(&pendingReview)
+	repository.DB.Model(&models.Report{}).Where("status = ?", models.ReportStatusOpen).Count(&openReports)
+
+	c.JSON(http.StatusOK, gin.H{
+		"totals": gin.H{
+			"users":          totalUsers,
+			"suspendedUsers": suspendedUsers,
+			"liveListings":   liveListings,
+			"pendingReview":  pendingReview,
+			"openReports":    openReports,
+		},
+		"interval": interval,
+		"from":     from,
+		"series":   series,
+	})
+}
+
+// loadAdminUser loads the user named by the id parameter, writing the error
+// response if there is none.
+func loadAdminUser(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+
+	var user models.User
+	result := repository.DB.First(&user, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
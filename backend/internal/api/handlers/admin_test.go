@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSuspendUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		active     bool
+		body       string
+		wantCode   int
+		wantActive bool
+	}{
+		{"active user", "2", true, `{"reason": "Scam listings"}`, http.StatusOK, false},
+		{"without a reason", "2", true, `{}`, http.StatusBadRequest, true},
+		{"already suspended", "2", false, `{"reason": "Scam listings"}`, http.StatusConflict, false},
+		{"yourself", "1", true, `{"reason": "Testing"}`, http.StatusBadRequest, true},
+		{"unknown user", "9", true, `{"reason": "Scam listings"}`, http.StatusNotFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("users", map[string]driver.Value{"id": int64(1), "role": "admin", "is_active": true})
+			db.insert("users", map[string]driver.Value{"id": int64(2), "role": "user", "is_active": tt.active})
+			db.insert("sessions", map[string]driver.Value{"id": int64(1), "user_id": int64(2), "revoked_at": nil})
+			db.insert("sessions", map[string]driver.Value{"id": int64(2), "user_id": int64(1), "revoked_at": nil})
+
+			c, w := newTestContext(http.MethodPost, "/admin/users/"+tt.userID+"/suspend", tt.body, 1, gin.Param{Key: "id", Value: tt.userID})
+			NewAdminHandler().SuspendUser(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			user := db.rows("users")[1]
+			if user["is_active"] != tt.wantActive {
+				t.Errorf("is_active = %v, want %v", user["is_active"], tt.wantActive)
+			}
+			sessions := db.rows("sessions")
+			if sessions[1]["revoked_at"] != nil {
+				t.Error("the admin's own session was revoked")
+			}
+
+			if tt.wantCode != http.StatusOK {
+				if sessions[0]["revoked_at"] != nil || len(db.rows("audit_entries")) != 0 {
+					t.Error("a rejected suspension revoked sessions or wrote to the audit log")
+				}
+				return
+			}
+			if user["suspension_reason"] != "Scam listings" || user["suspended_at"] == nil {
+				t.Errorf("suspension recorded as %v at %v", user["suspension_reason"], user["suspended_at"])
+			}
+			if sessions[0]["revoked_at"] == nil {
+				t.Error("the suspended user's session was not revoked")
+			}
+			if audits := db.rows("audit_entries"); len(audits) != 1 || audits[0]["action"] != models.AuditUserSuspend {
+				t.Errorf("audit entries = %v, want one suspension", audits)
+			}
+		})
+	}
+}
+
+func TestReactivateUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		active   bool
+		wantCode int
+	}{
+		{"suspended user", false, http.StatusOK},
+		{"active user", true, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			user := map[string]driver.Value{"id": int64(2), "is_active": tt.active}
+			if !tt.active {
+				user["suspended_at"], user["suspension_reason"] = time.Now(), "Scam listings"
+			}
+			db.insert("users", user)
+
+			c, w := newTestContext(http.MethodPost, "/admin/users/2/reactivate", "", 1, gin.Param{Key: "id", Value: "2"})
+			NewAdminHandler().ReactivateUser(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			stored := db.rows("users")[0]
+			if stored["is_active"] != true {
+				t.Errorf("is_active = %v, want true", stored["is_active"])
+			}
+			if tt.wantCode == http.StatusOK {
+				if stored["suspended_at"] != nil || stored["suspension_reason"] != "" {
+					t.Errorf("suspension kept: %v, %q", stored["suspended_at"], stored["suspension_reason"])
+				}
+				if audits := db.rows("audit_entries"); len(audits) != 1 || audits[0]["action"] != models.AuditUserReactivate {
+					t.Errorf("audit entries = %v, want one reactivation", audits)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreProduct(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		deleted    bool
+		wantCode   int
+		wantStatus string
+	}{
+		{"removed listing", "removed", false, http.StatusOK, "hidden"},
+		{"deleted live listing", "available", true, http.StatusOK, "hidden"},
+		{"deleted sold listing", "sold", true, http.StatusOK, "sold"},
+		{"live listing", "available", false, http.StatusConflict, "available"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			product := map[string]driver.Value{"id": int64(7), "user_id": int64(1), "status": tt.status, "deleted_at": nil}
+			if tt.deleted {
+				product["deleted_at"] = time.Now()
+			}
+			db.insert("products", product)
+
+			c, w := newTestContext(http.MethodPost, "/admin/products/7/restore", "", 9, gin.Param{Key: "id", Value: "7"})
+			NewAdminHandler().RestoreProduct(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			stored := db.rows("products")[0]
+			if stored["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", stored["status"], tt.wantStatus)
+			}
+			if tt.wantCode == http.StatusOK && stored["deleted_at"] != nil {
+				t.Errorf("deleted_at = %v, want it cleared", stored["deleted_at"])
+			}
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"50%", `50\%`},
+		{"first_name", `first\_name`},
+		{`back\slash`, `back\\slash`},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.in); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestChangeRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      string
+		role        string
+		wantCode    int
+		wantRole    string
+		wantRevoked bool
+	}{
+		{"promote to moderator", "2", "moderator", http.StatusOK, "moderator", true},
+		{"same role", "2", "user", http.StatusOK, "user", false},
+		{"unknown role", "2", "owner", http.StatusBadRequest, "user", false},
+		{"own role", "1", "user", http.StatusBadRequest, "user", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("users", map[string]driver.Value{"id": int64(2), "role": "user", "is_active": true})
+			db.insert("users", map[string]driver.Value{"id": int64(1), "role": "admin", "is_active": true})
+			db.insert("sessions", map[string]driver.Value{"id": int64(1), "user_id": int64(2), "revoked_at": nil})
+
+			c, w := newTestContext(http.MethodPut, "/admin/users/"+tt.userID+"/role", `{"role": "`+tt.role+`"}`, 1,
+				gin.Param{Key: "id", Value: tt.userID})
+			NewAdminHandler().ChangeRole(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			if role := db.rows("users")[0]["role"]; role != tt.wantRole {
+				t.Errorf("role = %v, want %s", role, tt.wantRole)
+			}
+			if admin := db.rows("users")[1]["role"]; admin != "admin" {
+				t.Errorf("admin's role = %v, want it unchanged", admin)
+			}
+			if revoked := db.rows("sessions")[0]["revoked_at"] != nil; revoked != tt.wantRevoked {
+				t.Errorf("session revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+			audits := db.rows("audit_entries")
+			if !tt.wantRevoked {
+				if len(audits) != 0 {
+					t.Errorf("%d audit entries written, want none", len(audits))
+				}
+				return
+			}
+			if len(audits) != 1 || audits[0]["action"] != models.AuditUserRoleChange {
+				t.Errorf("audit entries = %v, want one %s", audits, models.AuditUserRoleChange)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct{}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// auditEntry starts an audit entry for an action taken in this request,
+// filled in with the authenticated actor, client IP and user agent.
+func auditEntry(c *gin.Context, action, targetType string, targetID uint) *models.AuditEntry {
+	entry := &models.AuditEntry{
+		Action:     action,
+		TargetType: targetType,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if targetID != 0 {
+		entry.TargetID = &targetID
+	}
+	if userID, exists := c.Get("user_id"); exists {
+		actorID := userID.(uint)
+		entry.ActorID = &actorID
+	}
+	if email, exists := c.Get("user_email"); exists {
+		entry.ActorEmail, _ = email.(string)
+	}
+	return entry
+}
+
+// parseAuditTime accepts an RFC 3339 timestamp or a plain date.
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// GetAuditLog lists audit entries, newest first, filtered by actor, action,
+// target and time range.
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	// Query parameters
+	page, limit := pageParams(c, 50)
+	actorID := c.Query("actor_id")
+	action := c.Query("action")
+	targetType := c.Query("target_type")
+	targetID := c.Query("target_id")
+	from := c.Query("from")
+	to := c.Query("to")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.AuditEntry{})
+
+	if actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	if targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	if from != "" {
+		t, err := parseAuditTime(from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date or RFC 3339 timestamp"})
+			return
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+
+	if to != "" {
+		t, err := parseAuditTime(to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date or RFC 3339 timestamp"})
+			return
+		}
+		// A plain date includes the whole day
+		if len(to) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1)
+		}
+		query = query.Where("created_at < ?", t)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var entries []models.AuditEntry
+	result := query.Order("id DESC").Limit(limit).Offset(offset).Find(&entries)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries":    entries,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// VerifyAuditLog checks the hash chain of the whole audit log and reports
+// the first entry that does not match, if any.
+func (h *AuditHandler) VerifyAuditLog(c *gin.Context) {
+	checked, badID, err := audit.Verify(repository.DB)
+	if err != nil && !errors.Is(err, audit.ErrBrokenChain) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit log"})
+		return
+	}
+
+	response := gin.H{"valid": err == nil, "checked": checked}
+	if err != nil {
+		response["firstInvalidId"] = badID
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -1,19 +1,26 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
-	"strconv"
 	"strings"
 
-	"bech-do-backend/internal/api/middleware"
 	"bech-do-backend/internal/models"
 	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/audit"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// normalizeEmail is how accounts are keyed by email. Sign-up stores it, and
+// sign-in, its throttle and password resets look accounts up by it against
+// LOWER(email), so variants of one address are one account.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 type AuthHandler struct{}
 
 func NewAuthHandler() *AuthHandler {
@@ -39,8 +46,10 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refreshToken"`
+	ExpiresIn    int         `json:"expiresIn"`
+	User         models.User `json:"user"`
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -50,9 +59,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	email := normalizeEmail(req.Email)
+
 	// Check if user already exists
 	var existingUser models.User
-	result := repository.DB.Where("email = ?", req.Email).First(&existingUser)
+	result := repository.DB.Where("LOWER(email) = ?", email).First(&existingUser)
 	if result.Error == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists with this email"})
 		return
@@ -67,7 +78,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// Create user
 	user := models.User{
-		Email:       req.Email,
+		Email:       email,
 		Password:    string(hashedPassword),
 		Username:    req.Username,
 		FirstName:   req.FirstName,
@@ -91,20 +102,19 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateJWT(user.ID, user.Email, user.Role)
+	// The verification email is sent by a job
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to queue verification email to user %d: %v", user.ID, err)
+	}
+
+	// Open a session and generate tokens
+	response, err := issueTokens(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	// Remove password from response
-	user.Password = ""
-
-	c.JSON(http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusCreated, response)
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -114,39 +124,54 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	email := normalizeEmail(req.Email)
+
+	// Refuse attempts from an email or IP that failed too often
+	blockedUntil, err := loginBlockedUntil(email, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !blockedUntil.IsZero() {
+		respondLoginBlocked(c, blockedUntil)
+		return
+	}
+
 	// Find user by email
 	var user models.User
-	result := repository.DB.Where("email = ? AND is_active = ?", req.Email, true).First(&user)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
-			return
-		}
+	result := repository.DB.Where("LOWER(email) = ? AND is_active = ?", email, true).First(&user)
+	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	// Check password
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
-	if err != nil {
+	// Check password; unknown emails are checked against a dummy hash so
+	// they take as long as wrong passwords
+	passwordHash := user.Password
+	if result.Error == gorm.ErrRecordNotFound {
+		passwordHash = dummyPasswordHash
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password))
+	if err != nil || result.Error == gorm.ErrRecordNotFound {
+		if err := recordLoginFailure(c, email); err != nil {
+			log.Printf("Failed to record failed sign-in: %v", err)
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	// Generate JWT token
-	token, err := middleware.GenerateJWT(user.ID, user.Email, user.Role)
+	if err := clearLoginFailures(email); err != nil {
+		log.Printf("Failed to clear failed sign-ins for user %d: %v", user.ID, err)
+	}
+
+	// Open a session and generate tokens
+	response, err := issueTokens(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	// Remove password from response
-	user.Password = ""
-
-	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *AuthHandler) GetProfile(c *gin.Context) {
@@ -170,7 +195,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	// Remove password from response
 	user.Password = ""
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, gin.H{"user": user, "permissions": user.Role.Permissions()})
 }
 
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
@@ -180,14 +205,18 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	// Fields left out of the request keep their current value
 	var req struct {
-		FirstName string `json:"firstName"`
-		LastName  string `json:"lastName"`
-		Phone     string `json:"phone"`
-		Address   string `json:"address"`
-		City      string `json:"city"`
-		State     string `json:"state"`
-		PinCode   string `json:"pin_code"`
+		FirstName *string `json:"firstName"`
+		LastName  *string `json:"lastName"`
+		Phone     *string `json:"phone"`
+		Address   *string `json:"address"`
+		City      *string `json:"city"`
+		State     *string `json:"state"`
+		PinCode   *string `json:"pin_code"`
+
+		// Optional; only the types given are changed
+		NotificationPreferences models.NotificationPreferences `json:"notification_preferences"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -195,8 +224,14 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if err := req.NotificationPreferences.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Update user
-	updates := map[string]interface{}{
+	updates := make(map[string]interface{})
+	for column, value := range map[string]*string{
 		"first_name":   req.FirstName,
 		"last_name":    req.LastName,
 		"phone_number": req.Phone,
@@ -204,10 +239,26 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		"city":         req.City,
 		"state":        req.State,
 		"pin_code":     req.PinCode,
+	} {
+		if value != nil {
+			updates[column] = *value
+		}
 	}
 
-	result := repository.DB.Model(&models.User{}).Where("id = ?", userID).Updates(updates)
-	if result.Error != nil {
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if len(req.NotificationPreferences) > 0 {
+			if _, err := updateNotificationPreferences(tx, userID.(uint), req.NotificationPreferences); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
 	}
@@ -217,7 +268,10 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	repository.DB.First(&user, userID)
 	user.Password = ""
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, gin.H{
+		"user":                    user,
+		"notificationPreferences": user.NotificationPreferences.Effective(),
+	})
 }
 
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
@@ -260,9 +314,13 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	}
 
 	// Update password
-	userIDInt, _ := strconv.Atoi(userID.(string))
-	result = repository.DB.Model(&models.User{}).Where("id = ?", userIDInt).Update("password", string(hashedPassword))
-	if result.Error != nil {
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, models.AuditPasswordChange, models.AuditTargetUser, user.ID))
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setAuthConfig(t *testing.T) {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{
+		JWTSecret:       "test-secret",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	}
+	t.Cleanup(func() { config.AppConfig = previous })
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email, want string
+	}{
+		{"user@example.com", "user@example.com"},
+		{"User@Example.COM", "user@example.com"},
+		{"  user@example.com\t", "user@example.com"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeEmail(tt.email); got != tt.want {
+			t.Errorf("normalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterNormalizesEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setAuthConfig(t)
+
+	tests := []struct {
+		name      string
+		existing  string
+		email     string
+		wantCode  int
+		wantEmail string
+	}{
+		{"new address", "", "Bob@Example.com", http.StatusCreated, "bob@example.com"},
+		{"same address", "bob@example.com", "bob@example.com", http.StatusConflict, ""},
+		{"address in another case", "bob@example.com", "BOB@example.com", http.StatusConflict, ""},
+		{"stored in another case", "Bob@Example.com", "bob@example.com", http.StatusConflict, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			if tt.existing != "" {
+				db.insert("users", map[string]driver.Value{"id": int64(1), "email": tt.existing})
+			}
+
+			body := `{"email": "` + tt.email + `", "password": "secret123", "username": "bob", "firstName": "Bob", "lastName": "B"}`
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			NewAuthHandler().Register(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantEmail == "" {
+				if n := len(db.rows("users")); n != 1 {
+					t.Errorf("%d users stored, want only the existing one", n)
+				}
+				return
+			}
+			users := db.rows("users")
+			if len(users) != 1 || users[0]["email"] != tt.wantEmail {
+				t.Errorf("stored users = %v, want one with email %q", users, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestIssuePasswordResetMatchesEmailCase(t *testing.T) {
+	tests := []struct {
+		stored, requested string
+		wantJob           bool
+	}{
+		{"bob@example.com", "bob@example.com", true},
+		{"Bob@Example.com", "bob@example.com", true},
+		{"bob@example.com", "alice@example.com", false},
+	}
+	for _, tt := range tests {
+		db := useFakeDB(t)
+		db.insert("users", map[string]driver.Value{"id": int64(1), "email": tt.stored, "is_active": true})
+
+		NewAuthHandler().issuePasswordReset(normalizeEmail(tt.requested), "203.0.113.7")
+
+		if got := len(db.rows("jobs")) == 1; got != tt.wantJob {
+			t.Errorf("reset for %q with %q stored queued a job = %v, want %v", tt.requested, tt.stored, got, tt.wantJob)
+		}
+	}
+}
+
+func TestUpdateProfileKeepsFieldsLeftOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		body      string
+		want      map[string]driver.Value
+		wantPrefs string
+	}{
+		{
+			"only preferences",
+			`{"notification_preferences": {"price_drop": {"inApp": true, "email": false}}}`,
+			map[string]driver.Value{"first_name": "Asha", "city": "Pune", "pin_code": "411001"},
+			`{"message":{"inApp":true,"email":false},"price_drop":{"inApp":true,"email":false}}`,
+		},
+		{
+			"one field",
+			`{"city": "Mumbai"}`,
+			map[string]driver.Value{"first_name": "Asha", "city": "Mumbai", "pin_code": "411001"},
+			`{"message":{"inApp":true,"email":false}}`,
+		},
+		{
+			"field cleared",
+			`{"pin_code": ""}`,
+			map[string]driver.Value{"first_name": "Asha", "city": "Pune", "pin_code": ""},
+			`{"message":{"inApp":true,"email":false}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("users", map[string]driver.Value{
+				"id": int64(1), "first_name": "Asha", "city": "Pune", "pin_code": "411001",
+				"notification_preferences": `{"message":{"inApp":true,"email":false}}`,
+			})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/user/profile", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("user_id", uint(1))
+
+			NewAuthHandler().UpdateProfile(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			user := db.rows("users")[0]
+			for column, want := range tt.want {
+				if user[column] != want {
+					t.Errorf("%s = %v, want %v", column, user[column], want)
+				}
+			}
+			if prefs := user["notification_preferences"]; prefs != tt.wantPrefs {
+				t.Errorf("notification_preferences = %v, want %s", prefs, tt.wantPrefs)
+			}
+		})
+	}
+}
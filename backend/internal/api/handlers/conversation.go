@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMessagePageSize = 50
+	maxMessagePageSize     = 100
+)
+
+var errUserBlocked = errors.New("messaging between these users is blocked")
+
+type ConversationHandler struct{}
+
+func NewConversationHandler() *ConversationHandler {
+	return &ConversationHandler{}
+}
+
+type StartConversationRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Message   string `json:"message" binding:"required,max=2000"`
+}
+
+type SendMessageRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+}
+
+// participantColumns limits the user fields loaded for the other side of a
+// conversation, so contact details are not shared through messaging.
+func participantColumns(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "username", "first_name", "last_name", "city", "is_verified", "rating", "review_count", "created_at")
+}
+
+func (h *ConversationHandler) GetConversations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 20)
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Conversation{}).
+		Where("buyer_id = ? OR seller_id = ?", userID, userID)
+
+	if c.Query("unread") == "true" {
+		query = query.Where("EXISTS (SELECT 1 FROM messages m WHERE m.conversation_id = conversations.id AND m.sender_id <> ? AND m.read_at IS NULL)", userID)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var conversations []models.Conversation
+	result := query.
+		Select("conversations.*, (SELECT COUNT(*) FROM messages m WHERE m.conversation_id = conversations.id AND m.sender_id <> ? AND m.read_at IS NULL) AS unread_count", userID).
+		Preload("Product").
+		Preload("Buyer", participantColumns).
+		Preload("Seller", participantColumns).
+		Order("last_message_at DESC NULLS LAST, conversations.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&conversations)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+		return
+	}
+
+	if err := attachLastMessages(conversations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversations": conversations,
+		"pagination":    paginationInfo(page, limit, total),
+	})
+}
+
+func (h *ConversationHandler) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var count int64
+	result := repository.DB.Model(&models.Message{}).
+		Joins("JOIN conversations ON conversations.id = messages.conversation_id").
+		Where("(conversations.buyer_id = ? OR conversations.seller_id = ?)", userID, userID).
+		Where("messages.sender_id <> ? AND messages.read_at IS NULL", userID).
+		Count(&count)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count unread messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unreadCount": count})
+}
+
+// StartConversation opens the buyer's conversation about a product, or
+// reuses the existing one, and sends the first message.
+func (h *ConversationHandler) StartConversation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req StartConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(req.Message)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message cannot be empty"})
+		return
+	}
+
+	var product models.Product
+	result := repository.DB.First(&product, req.ProductID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	buyerID := userID.(uint)
+	if product.UserID == buyerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot message yourself about your own product"})
+		return
+	}
+
+	var conversation models.Conversation
+	result = repository.DB.Where("product_id = ? AND buyer_id = ?", product.ID, buyerID).First(&conversation)
+	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// New conversations can only be started about listings that are for sale
+	isNew := result.Error == gorm.ErrRecordNotFound
+	if isNew && product.Status != models.ProductStatusAvailable {
+		c.JSON(http.StatusConflict, gin.H{"error": "This product is no longer available"})
+		return
+	}
+
+	var message *models.Message
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if isNew {
+			conversation = models.Conversation{
+				ProductID: product.ID,
+				BuyerID:   buyerID,
+				SellerID:  product.UserID,
+			}
+			// A concurrent request may have opened it first
+			if err := tx.Where("product_id = ? AND buyer_id = ?", product.ID, buyerID).
+				FirstOrCreate(&conversation).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		message, err = sendMessage(tx, &conversation, buyerID, body)
+		return err
+	})
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	publishMessage(&conversation, message)
+
+	status := http.StatusOK
+	if isNew {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"conversation": conversation, "message": message})
+}
+
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conversation, ok := loadConversation(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultMessagePageSize)))
+	if limit <= 0 || limit > maxMessagePageSize {
+		limit = defaultMessagePageSize
+	}
+
+	// Messages are paged backwards from the newest with ?before=<message id>
+	query := repository.DB.Where("conversation_id = ?", conversation.ID)
+	if before, err := strconv.Atoi(c.Query("before")); err == nil && before > 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var messages []models.Message
+	if err := query.Order("id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		return
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	// Return the page in chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	// Opening the thread marks incoming messages as read
+	if err := markConversationRead(conversation, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update read receipts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversation": conversation,
+		"messages":     messages,
+		"hasMore":      hasMore,
+	})
+}
+
+func (h *ConversationHandler) SendMessage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(req.Message)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message cannot be empty"})
+		return
+	}
+
+	conversation, ok := loadConversation(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	var message *models.Message
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		message, err = sendMessage(tx, conversation, userID.(uint), body)
+		return err
+	})
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	publishMessage(conversation, message)
+
+	c.JSON(http.StatusCreated, gin.H{"message": message})
+}
+
+func (h *ConversationHandler) MarkRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	conversation, ok := loadConversation(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if err := markConversationRead(conversation, userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update read receipts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation marked as read"})
+}
+
+func (h *ConversationHandler) BlockUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	blockedID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || blockedID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	if uint(blockedID) == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+		return
+	}
+
+	var blocked models.User
+	if err := repository.DB.Select("id").First(&blocked, blockedID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	block := models.UserBlock{BlockerID: userID.(uint), BlockedID: blocked.ID}
+	result := repository.DB.Where("blocker_id = ? AND blocked_id = ?", block.BlockerID, block.BlockedID).
+		FirstOrCreate(&block)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+}
+
+func (h *ConversationHandler) UnblockUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	blockedID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || blockedID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	result := repository.DB.Where("blocker_id = ? AND blocked_id = ?", userID, blockedID).Delete(&models.UserBlock{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// loadConversation loads the conversation named by the :id parameter. Users
+// who are not participants get a 404, so thread IDs cannot be probed.
+func loadConversation(c *gin.Context, userID uint) (*models.Conversation, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return nil, false
+	}
+
+	var conversation models.Conversation
+	result := repository.DB.
+		Where("id = ? AND (buyer_id = ? OR seller_id = ?)", id, userID, userID).
+		Preload("Product").
+		Preload("Buyer", participantColumns).
+		Preload("Seller", participantColumns).
+		First(&conversation)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	return &conversation, true
+}
+
+// sendMessage stores a message from senderID unless either participant has
+// blocked the other.
+func sendMessage(tx *gorm.DB, conversation *models.Conversation, senderID uint, body string) (*models.Message, error) {
+	blocked, err := usersBlocked(tx, conversation.BuyerID, conversation.SellerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, errUserBlocked
+	}
+
+	message := models.Message{
+		ConversationID: conversation.ID,
+		SenderID:       senderID,
+		Body:           body,
+	}
+	if err := tx.Create(&message).Error; err != nil {
+		return nil, err
+	}
+
+	now := message.CreatedAt
+	if err := tx.Model(&models.Conversation{}).Where("id = ?", conversation.ID).
+		Update("last_message_at", now).Error; err != nil {
+		return nil, err
+	}
+	conversation.LastMessageAt = &now
+
+	return &message, nil
+}
+
+func usersBlocked(db *gorm.DB, a, b uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// markConversationRead marks the messages the user received as read and
+// sends a read receipt to the other participant.
+func markConversationRead(conversation *models.Conversation, userID uint) error {
+	readAt := time.Now()
+	result := repository.DB.Model(&models.Message{}).
+		Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversation.ID, userID).
+		Update("read_at", readAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		realtime.Publish("conversation.read", gin.H{
+			"conversationId": conversation.ID,
+			"readerId":       userID,
+			"readAt":         readAt,
+		}, otherParticipant(conversation, userID))
+	}
+	return nil
+}
+
+// publishMessage notifies both participants of a new message, so the
+// sender's other sessions stay in sync too.
+func publishMessage(conversation *models.Conversation, message *models.Message) {
+	events.Emit(context.Background(), events.MessageCreated, gin.H{
+		"conversationId": conversation.ID,
+		"productId":      conversation.ProductID,
+		"message":        message,
+	}, conversation.BuyerID, conversation.SellerID)
+}
+
+func otherParticipant(conversation *models.Conversation, userID uint) uint {
+	if conversation.BuyerID == userID {
+		return conversation.SellerID
+	}
+	return conversation.BuyerID
+}
+
+// attachLastMessages fills in the most recent message of each conversation.
+func attachLastMessages(conversations []models.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+
+	var messages []models.Message
+	err := repository.DB.Raw(
+		"SELECT DISTINCT ON (conversation_id) * FROM messages WHERE conversation_id IN ? ORDER BY conversation_id, id DESC",
+		ids,
+	).Scan(&messages).Error
+	if err != nil {
+		return err
+	}
+
+	byConversation := make(map[uint]models.Message, len(messages))
+	for _, message := range messages {
+		byConversation[message.ConversationID] = message
+	}
+	for i := range conversations {
+		if message, ok := byConversation[conversations[i].ID]; ok {
+			conversations[i].LastMessage = &message
+		}
+	}
+	return nil
+}
+
+func respondMessageError(c *gin.Context, err error) {
+	if err == errUserBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot message this user"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
+}
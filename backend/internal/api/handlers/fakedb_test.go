@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB is an in-memory stand-in for Postgres, just smart enough for the
+// queries of the handlers under test: SELECTs of one table filtered by
+// simple conditions joined with AND and OR, and INSERTs, UPDATEs and
+// DELETEs of whole rows, counter steps such as "count + 1" included.
+// Queries it does not understand return no rows. Every statement is
+// recorded.
+type fakeDB struct {
+	mu         sync.Mutex
+	tables     map[string][]map[string]driver.Value
+	uniques    map[string][][]string
+	statements []fakeStatement
+}
+
+// fakeStatement is a statement the fakeDB ran.
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// useFakeDB points repository.DB at a new fakeDB for the test.
+func useFakeDB(t *testing.T) *fakeDB {
+	t.Helper()
+	f := &fakeDB{tables: make(map[string][]map[string]driver.Value), uniques: make(map[string][][]string)}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(f)}), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := repository.DB
+	repository.DB = db
+	t.Cleanup(func() { repository.DB = previous })
+	return f
+}
+
+// insert adds a row to a table.
+func (f *fakeDB) insert(table string, row map[string]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tables[table] = append(f.tables[table], row)
+}
+
+// unique adds a unique constraint on columns of a table. Conflicting INSERTs
+// fail like Postgres's, or insert nothing with ON CONFLICT DO NOTHING.
+func (f *fakeDB) unique(table string, columns ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uniques[table] = append(f.uniques[table], columns)
+}
+
+// rows returns the rows of a table.
+func (f *fakeDB) rows(table string) []map[string]driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tables[table]
+}
+
+// executed returns the recorded statements that start with prefix.
+func (f *fakeDB) executed(prefix string) []fakeStatement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []fakeStatement
+	for _, statement := range f.statements {
+		if strings.HasPrefix(statement.query, prefix) {
+			matched = append(matched, statement)
+		}
+	}
+	return matched
+}
+
+var (
+	fakeTablePattern   = regexp.MustCompile(`(?:FROM|INTO|UPDATE) "(\w+)"`)
+	fakeEqualPattern   = regexp.MustCompile(`^(LOWER\()?(?:"\w+"\.)?"?(\w+)"?\)? = \$(\d+)$`)
+	fakeNullPattern    = regexp.MustCompile(`^(?:"\w+"\.)?"?(\w+)"? IS (NOT )?NULL$`)
+	fakeComparePattern = regexp.MustCompile(`^(?:"\w+"\.)?"?(\w+)"? (<=|>=|<>|<|>|IN) \(?(\$\d+(?:,\s*\$\d+)*)\)?$`)
+	fakeStepPattern    = regexp.MustCompile(`"(\w+)"=(GREATEST\()?(\w+) ([+-]) (\d+)`)
+	fakeSetPattern     = regexp.MustCompile(`"(\w+)"=\$(\d+)`)
+	fakeColumnPattern  = regexp.MustCompile(`^(?:"?\w+"?\.)?"?(\w+)"?$`)
+)
+
+// query runs a statement and returns its rows and how many rows it changed.
+func (f *fakeDB) query(query string, args []driver.NamedValue) (driver.Rows, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	statement := fakeStatement{query: query}
+	for _, arg := range args {
+		statement.args = append(statement.args, arg.Value)
+	}
+	f.statements = append(f.statements, statement)
+
+	match := fakeTablePattern.FindStringSubmatch(query)
+	if match == nil {
+		return &fakeRows{}, 0, nil
+	}
+	table := match[1]
+
+	switch {
+	case strings.HasPrefix(query, "INSERT"):
+		return f.insertRow(table, query, args)
+	case strings.HasPrefix(query, "UPDATE"):
+		return &fakeRows{}, f.update(table, query, args), nil
+	case strings.HasPrefix(query, "DELETE"):
+		return &fakeRows{}, f.delete(table, query, args), nil
+	case strings.HasPrefix(query, "SELECT"):
+		return f.selectRows(table, query, args), 0, nil
+	}
+	return &fakeRows{}, 0, nil
+}
+
+// where returns the rows of the table matching the WHERE clause of the
+// query. Equalities, comparisons and IN on columns the rows have are
+// checked, and IS NULL with missing columns taken as NULL; other conditions
+// hold for every row.
+func (f *fakeDB) where(table, query string, args []driver.NamedValue) []map[string]driver.Value {
+	clause := ""
+	if i := strings.Index(query, " WHERE "); i >= 0 {
+		clause = query[i+len(" WHERE "):]
+		for _, end := range []string{" ORDER BY ", " GROUP BY ", " LIMIT ", " FOR UPDATE", " RETURNING "} {
+			if j := strings.Index(clause, end); j >= 0 {
+				clause = clause[:j]
+			}
+		}
+	}
+	var matched []map[string]driver.Value
+	for _, row := range f.tables[table] {
+		if clause == "" || fakeMatches(row, clause, args) {
+			matched = append(matched, row)
+		}
+	}
+	return matched
+}
+
+// fakeMatches evaluates a condition of ANDs and ORs against a row.
+func fakeMatches(row map[string]driver.Value, expr string, args []driver.NamedValue) bool {
+	expr = strings.TrimSpace(expr)
+	for strings.HasPrefix(expr, "(") && fakeClosing(expr, 0) == len(expr)-1 {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+
+	if terms := fakeSplit(expr, " OR "); len(terms) > 1 {
+		for _, term := range terms {
+			if fakeMatches(row, term, args) {
+				return true
+			}
+		}
+		return false
+	}
+	if terms := fakeSplit(expr, " AND "); len(terms) > 1 {
+		for _, term := range terms {
+			if !fakeMatches(row, term, args) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if cond := fakeNullPattern.FindStringSubmatch(expr); cond != nil {
+		isNull := row[cond[1]] == nil
+		return isNull == (cond[2] == "")
+	}
+	if cond := fakeComparePattern.FindStringSubmatch(expr); cond != nil {
+		value, exists := row[cond[1]]
+		if !exists {
+			return true
+		}
+		var params []driver.Value
+		for _, param := range strings.Split(cond[3], ",") {
+			n, _ := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(param), "$"))
+			params = append(params, args[n-1].Value)
+		}
+		return fakeCompare(value, cond[2], params)
+	}
+
+	cond := fakeEqualPattern.FindStringSubmatch(expr)
+	if cond == nil {
+		return true
+	}
+	value, exists := row[cond[2]]
+	if !exists {
+		return true
+	}
+	n, _ := strconv.Atoi(cond[3])
+	got, want := fmt.Sprint(value), fmt.Sprint(args[n-1].Value)
+	if cond[1] != "" {
+		got = strings.ToLower(got)
+	}
+	return got == want
+}
+
+// fakeCompare applies a comparison operator. Values of types it cannot
+// order compare as true.
+func fakeCompare(value driver.Value, op string, params []driver.Value) bool {
+	if op == "IN" {
+		for _, param := range params {
+			if fmt.Sprint(value) == fmt.Sprint(param) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var order int
+	switch v := value.(type) {
+	case time.Time:
+		p, ok := params[0].(time.Time)
+		if !ok {
+			return true
+		}
+		order = v.Compare(p)
+	case int64, float64:
+		a, _ := strconv.ParseFloat(fmt.Sprint(v), 64)
+		b, err := strconv.ParseFloat(fmt.Sprint(params[0]), 64)
+		if err != nil {
+			return true
+		}
+		order = cmp.Compare(a, b)
+	default:
+		return true
+	}
+
+	switch op {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return order != 0
+}
+
+// fakeSplit splits expr on sep outside parentheses.
+func fakeSplit(expr, sep string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(expr[i:], sep) {
+				terms = append(terms, expr[start:i])
+				start = i + len(sep)
+				i += len(sep) - 1
+			}
+		}
+	}
+	return append(terms, expr[start:])
+}
+
+// fakeClosing returns the index of the parenthesis closing the one at open.
+func fakeClosing(expr string, open int) int {
+	depth := 0
+	for i := open; i < len(expr); i++ {
+		switch expr[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (f *fakeDB) selectRows(table, query string, args []driver.NamedValue) driver.Rows {
+	rows := f.where(table, query, args)
+
+	list := query[len("SELECT "):strings.Index(query, " FROM ")]
+	if strings.HasPrefix(strings.ToLower(list), "count(") {
+		return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{int64(len(rows))}}}
+	}
+
+	var columns []string
+	if list == "*" || strings.HasSuffix(list, ".*") {
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			for column := range row {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		sort.Strings(columns)
+	} else {
+		for _, column := range strings.Split(list, ",") {
+			if m := fakeColumnPattern.FindStringSubmatch(strings.TrimSpace(column)); m != nil {
+				columns = append(columns, m[1])
+			}
+		}
+	}
+
+	if strings.Contains(query, " LIMIT 1") && len(rows) > 1 {
+		rows = rows[:1]
+	}
+	result := &fakeRows{columns: columns}
+	for _, row := range rows {
+		values := make([]driver.Value, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		result.values = append(result.values, values)
+	}
+	return result
+}
+
+func (f *fakeDB) insertRow(table, query string, args []driver.NamedValue) (driver.Rows, int64, error) {
+	open := strings.Index(query, "(")
+	close := strings.Index(query, ")")
+	row := make(map[string]driver.Value)
+	for i, column := range strings.Split(query[open+1:close], ",") {
+		if i < len(args) {
+			row[strings.Trim(strings.TrimSpace(column), `"`)] = args[i].Value
+		}
+	}
+
+	for _, columns := range f.uniques[table] {
+		for _, existing := range f.tables[table] {
+			conflict := true
+			for _, column := range columns {
+				if fmt.Sprint(existing[column]) != fmt.Sprint(row[column]) {
+					conflict = false
+				}
+			}
+			if !conflict {
+				continue
+			}
+			if strings.Contains(query, "ON CONFLICT DO NOTHING") {
+				return &fakeRows{}, 0, nil
+			}
+			return nil, 0, &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"}
+		}
+	}
+
+	var id int64
+	for _, existing := range f.tables[table] {
+		if n, ok := existing["id"].(int64); ok && n > id {
+			id = n
+		}
+	}
+	id++
+	row["id"] = id
+	f.tables[table] = append(f.tables[table], row)
+
+	if strings.Contains(query, "RETURNING") {
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{id}}}, 1, nil
+	}
+	return &fakeRows{}, 1, nil
+}
+
+func (f *fakeDB) update(table, query string, args []driver.NamedValue) int64 {
+	set := query[strings.Index(query, " SET ")+len(" SET "):]
+	if i := strings.Index(set, " WHERE "); i >= 0 {
+		set = set[:i]
+	}
+	rows := f.where(table, query, args)
+	for _, row := range rows {
+		for _, assignment := range fakeSetPattern.FindAllStringSubmatch(set, -1) {
+			n, _ := strconv.Atoi(assignment[2])
+			row[assignment[1]] = args[n-1].Value
+		}
+		for _, step := range fakeStepPattern.FindAllStringSubmatch(set, -1) {
+			current, _ := row[step[3]].(int64)
+			n, _ := strconv.ParseInt(step[5], 10, 64)
+			if step[4] == "-" {
+				n = -n
+			}
+			if step[2] != "" {
+				n = max(n, -current)
+			}
+			row[step[1]] = current + n
+		}
+	}
+	return int64(len(rows))
+}
+
+func (f *fakeDB) delete(table, query string, args []driver.NamedValue) int64 {
+	deleted := f.where(table, query, args)
+	var kept []map[string]driver.Value
+	for _, row := range f.tables[table] {
+		keep := true
+		for _, d := range deleted {
+			if fmt.Sprint(d["id"]) == fmt.Sprint(row["id"]) {
+				keep = false
+			}
+		}
+		if keep {
+			kept = append(kept, row)
+		}
+	}
+	f.tables[table] = kept
+	return int64(len(deleted))
+}
+
+// database/sql plumbing
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, _, err := c.db.query(query, args)
+	return rows, err
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	_, affected, err := c.db.query(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FavoriteHandler struct{}
+
+func NewFavoriteHandler() *FavoriteHandler {
+	return &FavoriteHandler{}
+}
+
+// AddFavorite saves a product to the user's favorites. Adding a product
+// twice is not an error.
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("productId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var product models.Product
+	result := repository.DB.First(&product, productID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if !product.Status.IsPublic() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	created := false
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		favorite := models.Favorite{UserID: userID.(uint), ProductID: product.ID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected > 0
+		if !created {
+			return nil
+		}
+		return tx.Model(&models.Product{}).Where("id = ?", product.ID).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count + 1")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save favorite"})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"message": "Product added to favorites"})
+}
+
+// RemoveFavorite removes a product from the user's favorites.
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("productId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND product_id = ?", userID, productID).Delete(&models.Favorite{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Product{}).Where("id = ?", productID).
+			UpdateColumn("favorite_count", gorm.Expr("GREATEST(favorite_count - 1, 0)")).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove favorite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product removed from favorites"})
+}
+
+// GetFavorites lists the user's favorited products, most recently saved
+// first. Listings that are no longer public are left out.
+func (h *FavoriteHandler) GetFavorites(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 12)
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Product{}).
+		Joins("JOIN favorites ON favorites.product_id = products.id").
+		Where("favorites.user_id = ?", userID).
+		Where("products.status NOT IN ?", []models.ProductStatus{
+			models.ProductStatusDraft, models.ProductStatusHidden, models.ProductStatusRemoved,
+		})
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var products []models.Product
+	result := query.
+		Preload("User").
+		Preload("Category").
+		Order("favorites.created_at DESC, favorites.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&products)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch favorites"})
+		return
+	}
+
+	productRefs := make([]*models.Product, len(products))
+	for i := range products {
+		products[i].IsFavorited = true
+		productRefs[i] = &products[i]
+	}
+	if err := attachSellers(productRefs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sellers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":   products,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// markFavorited sets IsFavorited on the products the requesting user has
+// favorited. Anonymous requests are left unmarked.
+func markFavorited(c *gin.Context, products ...*models.Product) error {
+	userID, exists := c.Get("user_id")
+	if !exists || len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	var favorited []uint
+	err := repository.DB.Model(&models.Favorite{}).
+		Where("user_id = ? AND product_id IN ?", userID, ids).
+		Pluck("product_id", &favorited).Error
+	if err != nil {
+		return err
+	}
+
+	set := make(map[uint]bool, len(favorited))
+	for _, id := range favorited {
+		set[id] = true
+	}
+	for _, product := range products {
+		product.IsFavorited = set[product.ID]
+	}
+	return nil
+}
+
+// favoritedBy returns the users who favorited a product.
+func favoritedBy(productID uint) ([]uint, error) {
+	var userIDs []uint
+	err := repository.DB.Model(&models.Favorite{}).
+		Where("product_id = ?", productID).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// notifyFavoriters emits an event to everyone who favorited the product.
+func notifyFavoriters(eventType string, product *models.Product, data gin.H) {
+	userIDs, err := favoritedBy(product.ID)
+	if err != nil {
+		log.Printf("Failed to load favorites of product %d: %v", product.ID, err)
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	data["productId"] = product.ID
+	data["title"] = product.Title
+	events.Emit(context.Background(), eventType, data, userIDs...)
+}
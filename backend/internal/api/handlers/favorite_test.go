@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAddFavorite(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		favorited bool
+		wantCode  int
+		wantCount int64
+	}{
+		{"available listing", "available", false, http.StatusCreated, 4},
+		{"reserved listing", "reserved", false, http.StatusCreated, 4},
+		{"already favorited", "available", true, http.StatusOK, 3},
+		{"draft", "draft", false, http.StatusNotFound, 3},
+		{"hidden", "hidden", false, http.StatusNotFound, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.unique("favorites", "user_id", "product_id")
+			db.insert("products", map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "status": tt.status, "favorite_count": int64(3),
+			})
+			if tt.favorited {
+				db.insert("favorites", map[string]driver.Value{"id": int64(1), "user_id": int64(2), "product_id": int64(7)})
+			}
+
+			c, w := newTestContext(http.MethodPost, "/favorites/7", "", 2, gin.Param{Key: "productId", Value: "7"})
+			NewFavoriteHandler().AddFavorite(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			wantFavorites := 0
+			if tt.favorited || tt.wantCode == http.StatusCreated {
+				wantFavorites = 1
+			}
+			if n := len(db.rows("favorites")); n != wantFavorites {
+				t.Errorf("%d favorites stored, want %d", n, wantFavorites)
+			}
+			if count := db.rows("products")[0]["favorite_count"]; count != tt.wantCount {
+				t.Errorf("favorite_count = %v, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRemoveFavorite(t *testing.T) {
+	tests := []struct {
+		name      string
+		favorited bool
+		count     int64
+		wantCount int64
+	}{
+		{"favorited", true, 3, 2},
+		{"not favorited", false, 3, 3},
+		{"count already zero", true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("products", map[string]driver.Value{"id": int64(7), "user_id": int64(1), "favorite_count": tt.count})
+			if tt.favorited {
+				db.insert("favorites", map[string]driver.Value{"id": int64(1), "user_id": int64(2), "product_id": int64(7)})
+			}
+			db.insert("favorites", map[string]driver.Value{"id": int64(2), "user_id": int64(3), "product_id": int64(7)})
+
+			c, w := newTestContext(http.MethodDelete, "/favorites/7", "", 2, gin.Param{Key: "productId", Value: "7"})
+			NewFavoriteHandler().RemoveFavorite(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d; body %s", w.Code, w.Body)
+			}
+			if favorites := db.rows("favorites"); len(favorites) != 1 || favorites[0]["user_id"] != int64(3) {
+				t.Errorf("favorites left = %v, want only the other user's", favorites)
+			}
+			if count := db.rows("products")[0]["favorite_count"]; count != tt.wantCount {
+				t.Errorf("favorite_count = %v, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestMarkFavorited(t *testing.T) {
+	db := useFakeDB(t)
+	db.insert("favorites", map[string]driver.Value{"id": int64(1), "user_id": int64(2), "product_id": int64(7)})
+	db.insert("favorites", map[string]driver.Value{"id": int64(2), "user_id": int64(3), "product_id": int64(8)})
+
+	tests := []struct {
+		name   string
+		userID uint
+		want   []bool
+	}{
+		{"signed in", 2, []bool{true, false}},
+		{"other user", 3, []bool{false, true}},
+		{"anonymous", 0, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		products := []*models.Product{{ID: 7}, {ID: 8}}
+		c, _ := newTestContext(http.MethodGet, "/products", "", tt.userID)
+		if err := markFavorited(c, products...); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for i, product := range products {
+			if product.IsFavorited != tt.want[i] {
+				t.Errorf("%s: product %d favorited = %v, want %v", tt.name, product.ID, product.IsFavorited, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNotifyFavoriters(t *testing.T) {
+	tests := []struct {
+		name       string
+		favoriters []int64
+	}{
+		{"favorited", []int64{2, 3}},
+		{"not favorited", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			for i, userID := range tt.favoriters {
+				db.insert("favorites", map[string]driver.Value{"id": int64(i + 1), "user_id": userID, "product_id": int64(7)})
+			}
+			sold := recordEvents(t, events.FavoriteSold)
+
+			notifyFavoriters(events.FavoriteSold, &models.Product{ID: 7, Title: "Desk"}, gin.H{})
+
+			emitted := sold()
+			if len(tt.favoriters) == 0 {
+				if len(emitted) != 0 {
+					t.Errorf("emitted %v, want nothing", emitted)
+				}
+				return
+			}
+			if len(emitted) != 1 || len(emitted[0].UserIDs) != len(tt.favoriters) {
+				t.Fatalf("emitted %v, want one event for %v", emitted, tt.favoriters)
+			}
+			data := emitted[0].Data.(gin.H)
+			if data["productId"] != uint(7) || data["title"] != "Desk" {
+				t.Errorf("event data = %v, want the product's id and title", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/audit"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dummyPasswordHash is compared against when no account matches the email,
+// so that failed sign-ins take as long whether or not the account exists.
+const dummyPasswordHash = "$2a$10$unFXoCtsn8sQ7TlpfB051ePcHMrxNbIKYOZLU8Nej9gb4h6Z.Rh9a"
+
+// loginThresholds returns after how many failures a key is delayed and
+// after how many it is locked out. IPs get more room, as many users may
+// share one.
+func loginThresholds(kind models.LoginThrottleKind) (delayAfter, lockoutAfter int) {
+	if kind == models.LoginThrottleIP {
+		return config.AppConfig.LoginIPDelayAfter, config.AppConfig.LoginIPLockoutAfter
+	}
+	return config.AppConfig.LoginDelayAfter, config.AppConfig.LoginLockoutAfter
+}
+
+// loginDelay is how long to wait before the next attempt after the given
+// number of failures: LOGIN_BASE_DELAY, doubling with every further failure,
+// up to LOGIN_MAX_DELAY.
+func loginDelay(failures, delayAfter int) time.Duration {
+	delay := float64(config.AppConfig.LoginBaseDelay) * math.Pow(2, float64(failures-delayAfter))
+	if delay > float64(config.AppConfig.LoginMaxDelay) {
+		return config.AppConfig.LoginMaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// loginBlockedUntil returns when the normalized email and IP may try to sign
+// in again, or the zero time if they may now.
+func loginBlockedUntil(email, ip string) (time.Time, error) {
+	var throttle models.LoginThrottle
+	err := repository.DB.
+		Where("(kind = ? AND key = ?) OR (kind = ? AND key = ?)",
+			models.LoginThrottleEmail, email, models.LoginThrottleIP, ip).
+		Where("blocked_until > ?", time.Now()).
+		Order("blocked_until DESC").
+		First(&throttle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return *throttle.BlockedUntil, nil
+}
+
+// respondLoginBlocked rejects a sign-in attempt made too soon. The response
+// is the same for delays and lockouts and for known and unknown emails.
+func respondLoginBlocked(c *gin.Context, until time.Time) {
+	retryAfter := int(math.Ceil(time.Until(until).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many sign-in attempts. Please try again later"})
+}
+
+// recordLoginFailure counts a failed sign-in against the normalized email
+// and the client IP, delaying or locking them out as they pass their thresholds.
+func recordLoginFailure(c *gin.Context, email string) error {
+	keys := []struct {
+		kind models.LoginThrottleKind
+		key  string
+	}{
+		{models.LoginThrottleEmail, email},
+		{models.LoginThrottleIP, c.ClientIP()},
+	}
+
+	return repository.DB.Transaction(func(tx *gorm.DB) error {
+		for _, k := range keys {
+			if err := countLoginFailure(c, tx, k.kind, k.key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func countLoginFailure(c *gin.Context, tx *gorm.DB, kind models.LoginThrottleKind, key string) error {
+	now := time.Now()
+
+	throttle := models.LoginThrottle{Kind: kind, Key: key, LastFailureAt: now}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&throttle).Error; err != nil {
+		return err
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("kind = ? AND key = ?", kind, key).
+		First(&throttle).Error
+	if err != nil {
+		return err
+	}
+
+	// Attempts that raced past the check while locked out change nothing
+	if throttle.LockedAt != nil && throttle.BlockedUntil != nil && now.Before(*throttle.BlockedUntil) {
+		return nil
+	}
+
+	// Start over once the last failure is stale or a lockout has run out
+	if throttle.LockedAt != nil || now.Sub(throttle.LastFailureAt) > config.AppConfig.LoginFailureWindow {
+		throttle.Failures = 0
+		throttle.LockedAt = nil
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	throttle.BlockedUntil = nil
+
+	delayAfter, lockoutAfter := loginThresholds(kind)
+	switch {
+	case throttle.Failures >= lockoutAfter:
+		until := now.Add(config.AppConfig.LoginLockoutDuration)
+		throttle.BlockedUntil = &until
+		throttle.LockedAt = &now
+	case throttle.Failures >= delayAfter:
+		until := now.Add(loginDelay(throttle.Failures, delayAfter))
+		throttle.BlockedUntil = &until
+	}
+
+	if err := tx.Save(&throttle).Error; err != nil {
+		return err
+	}
+
+	if throttle.LockedAt == nil {
+		return nil
+	}
+
+	// The request is unauthenticated, so the entry has no actor
+	entry := auditEntry(c, models.AuditLoginLockout, models.AuditTargetLoginThrottle, throttle.ID)
+	entry.Changes = map[string]interface{}{
+		"kind":        throttle.Kind,
+		"key":         throttle.Key,
+		"failures":    throttle.Failures,
+		"lockedUntil": throttle.BlockedUntil,
+	}
+	if kind == models.LoginThrottleEmail {
+		var userIDs []uint
+		if err := tx.Model(&models.User{}).Where("LOWER(email) = ?", key).Pluck("id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) > 0 {
+			entry.Changes["userId"] = userIDs[0]
+		}
+	}
+	return audit.Record(tx, entry)
+}
+
+// clearLoginFailures forgets the email's failures after a successful
+// sign-in. The IP's failures are left to expire, so that signing in to one
+// account does not reset an attack on others.
+func clearLoginFailures(email string) error {
+	return repository.DB.
+		Where("kind = ? AND key = ?", models.LoginThrottleEmail, email).
+		Delete(&models.LoginThrottle{}).Error
+}
+
+// CleanupLoginThrottles is the job that deletes throttles whose failures
+// are stale and that no longer block anyone.
+func CleanupLoginThrottles(ctx context.Context, job *models.Job) error {
+	now := time.Now()
+	return repository.DB.WithContext(ctx).
+		Where("last_failure_at < ?", now.Add(-config.AppConfig.LoginFailureWindow)).
+		Where("blocked_until IS NULL OR blocked_until < ?", now).
+		Delete(&models.LoginThrottle{}).Error
+}
+
+// GetLoginLockouts lists the emails and IPs currently delayed or locked out
+// after failed sign-ins.
+func (h *AdminHandler) GetLoginLockouts(c *gin.Context) {
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	kind := c.Query("kind")
+	search := strings.TrimSpace(c.Query("search"))
+	lockedOnly := c.Query("locked") == "true"
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.LoginThrottle{}).Where("blocked_until > ?", time.Now())
+
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	if search != "" {
+		query = query.Where("key ILIKE ?", "%"+escapeLike(strings.ToLower(search))+"%")
+	}
+
+	if lockedOnly {
+		query = query.Where("locked_at IS NOT NULL")
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var throttles []models.LoginThrottle
+	result := query.Order("blocked_until DESC").Limit(limit).Offset(offset).Find(&throttles)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lockouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lockouts":   throttles,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// UnlockLogin lifts a delay or lockout and forgets the failures behind it.
+func (h *AdminHandler) UnlockLogin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lockout ID"})
+		return
+	}
+
+	var throttle models.LoginThrottle
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&throttle, id).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&throttle).Error; err != nil {
+			return err
+		}
+
+		entry := auditEntry(c, models.AuditLoginUnlock, models.AuditTargetLoginThrottle, throttle.ID)
+		entry.Changes = map[string]interface{}{
+			"kind":         throttle.Kind,
+			"key":          throttle.Key,
+			"failures":     gin.H{"before": throttle.Failures, "after": 0},
+			"blockedUntil": gin.H{"before": throttle.BlockedUntil, "after": nil},
+			"locked":       throttle.LockedAt != nil,
+		}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lockout not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sign-in unlocked"})
+}
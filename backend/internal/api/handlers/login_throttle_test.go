@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+)
+
+func setLoginConfig(t *testing.T) {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{
+		LoginDelayAfter:     3,
+		LoginLockoutAfter:   10,
+		LoginIPDelayAfter:   20,
+		LoginIPLockoutAfter: 100,
+		LoginBaseDelay:      time.Second,
+		LoginMaxDelay:       time.Minute,
+	}
+	t.Cleanup(func() { config.AppConfig = previous })
+}
+
+func TestLoginThresholds(t *testing.T) {
+	setLoginConfig(t)
+
+	tests := []struct {
+		kind                   models.LoginThrottleKind
+		wantDelay, wantLockout int
+	}{
+		{models.LoginThrottleEmail, 3, 10},
+		{models.LoginThrottleIP, 20, 100},
+	}
+	for _, tt := range tests {
+		delayAfter, lockoutAfter := loginThresholds(tt.kind)
+		if delayAfter != tt.wantDelay || lockoutAfter != tt.wantLockout {
+			t.Errorf("loginThresholds(%s) = %d, %d; want %d, %d", tt.kind, delayAfter, lockoutAfter, tt.wantDelay, tt.wantLockout)
+		}
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	setLoginConfig(t)
+
+	tests := []struct {
+		failures, delayAfter int
+		want                 time.Duration
+	}{
+		{3, 3, time.Second},
+		{4, 3, 2 * time.Second},
+		{5, 3, 4 * time.Second},
+		{8, 3, 32 * time.Second},
+		{9, 3, time.Minute},
+		{50, 3, time.Minute},
+		{20, 20, time.Second},
+		{23, 20, 8 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures, tt.delayAfter); got != tt.want {
+			t.Errorf("loginDelay(%d, %d) = %v, want %v", tt.failures, tt.delayAfter, got, tt.want)
+		}
+	}
+}
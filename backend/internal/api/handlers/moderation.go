@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/audit"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/lifecycle"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errReportResolved is returned when a report was resolved concurrently.
+var errReportResolved = errors.New("report already resolved")
+
+// moderationAuditActions names moderators' decisions in the audit log.
+var moderationAuditActions = map[lifecycle.Action]string{
+	lifecycle.ActionApprove:  models.AuditProductApprove,
+	lifecycle.ActionTakeDown: models.AuditProductTakeDown,
+	lifecycle.ActionRemove:   models.AuditProductRemove,
+}
+
+type ModerationHandler struct{}
+
+func NewModerationHandler() *ModerationHandler {
+	return &ModerationHandler{}
+}
+
+type ReportRequest struct {
+	Reason  models.ReportReason `json:"reason" binding:"required"`
+	Details string              `json:"details" binding:"max=1000"`
+}
+
+type ModerationRequest struct {
+	// Reason is shown to the seller; taking down and removing require it
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type DismissReportRequest struct {
+	Resolution string `json:"resolution" binding:"max=500"`
+}
+
+// moderationQueueItem is a listing waiting for a moderator, with a summary
+// of its open reports.
+type moderationQueueItem struct {
+	Product         models.Product              `json:"product"`
+	ReportCount     int                         `json:"reportCount"`
+	Reasons         map[models.ReportReason]int `json:"reasons"`
+	FirstReportedAt *time.Time                  `json:"firstReportedAt,omitempty"`
+}
+
+// ReportProduct lets a user flag a public listing for moderators. Each user
+// can report a listing once.
+func (h *ModerationHandler) ReportProduct(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.Reason.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report reason"})
+		return
+	}
+	req.Details = strings.TrimSpace(req.Details)
+	if req.Reason == models.ReportReasonOther && req.Details == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Please describe the problem"})
+		return
+	}
+
+	var product models.Product
+	result := repository.DB.Select("id", "user_id", "status").First(&product, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if !product.Status.IsPublic() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	if product.UserID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own listing"})
+		return
+	}
+
+	report := models.Report{
+		ProductID:  product.ID,
+		ReporterID: userID.(uint),
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     models.ReportStatusOpen,
+	}
+	if err := repository.DB.Create(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this listing"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report product"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"report": report})
+}
+
+// GetQueue lists the listings waiting for a moderator: those with open
+// reports, most reported first, followed by those pending review, oldest
+// first. Pass type=reported or type=pending_review to see one kind.
+func (h *ModerationHandler) GetQueue(c *gin.Context) {
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	queueType := c.Query("type")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Table("products").
+		Joins(`LEFT JOIN (
+			SELECT product_id, COUNT(*) AS report_count, MIN(created_at) AS first_reported_at
+			FROM reports WHERE status = ? GROUP BY product_id
+		) open_reports ON open_reports.product_id = products.id`, models.ReportStatusOpen).
+		Where("products.deleted_at IS NULL")
+
+	switch queueType {
+	case "reported":
+		query = query.Where("open_reports.product_id IS NOT NULL")
+	case string(models.ProductStatusPendingReview):
+		query = query.Where("products.status = ?", models.ProductStatusPendingReview)
+	case "":
+		query = query.Where("open_reports.product_id IS NOT NULL OR products.status = ?", models.ProductStatusPendingReview)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be reported or pending_review"})
+		return
+	}
+
+	// Count total results
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation queue"})
+		return
+	}
+
+	var rows []struct {
+		ProductID       uint
+		ReportCount     int
+		FirstReportedAt *time.Time
+	}
+	result := query.
+		Select("products.id AS product_id, COALESCE(open_reports.report_count, 0) AS report_count, open_reports.first_reported_at").
+		Order("report_count DESC, COALESCE(open_reports.first_reported_at, products.created_at), products.id").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation queue"})
+		return
+	}
+
+	items := make([]moderationQueueItem, 0, len(rows))
+	if len(rows) > 0 {
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ProductID
+		}
+
+		var products []models.Product
+		if err := repository.DB.Preload("User").Preload("Category").Where("id IN ?", ids).Find(&products).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation queue"})
+			return
+		}
+		productRefs := make([]*models.Product, len(products))
+		for i := range products {
+			productRefs[i] = &products[i]
+		}
+		if err := attachSellers(productRefs...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sellers"})
+			return
+		}
+		byID := make(map[uint]models.Product, len(products))
+		for _, product := range products {
+			byID[product.ID] = product
+		}
+
+		var reasonCounts []struct {
+			ProductID uint
+			Reason    models.ReportReason
+			Count     int
+		}
+		err := repository.DB.Model(&models.Report{}).
+			Select("product_id, reason, COUNT(*) AS count").
+			Where("product_id IN ? AND status = ?", ids, models.ReportStatusOpen).
+			Group("product_id, reason").
+			Scan(&reasonCounts).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation queue"})
+			return
+		}
+		reasons := make(map[uint]map[models.ReportReason]int)
+		for _, rc := range reasonCounts {
+			if reasons[rc.ProductID] == nil {
+				reasons[rc.ProductID] = make(map[models.ReportReason]int)
+			}
+			reasons[rc.ProductID][rc.Reason] = rc.Count
+		}
+
+		for _, row := range rows {
+			product, ok := byID[row.ProductID]
+			if !ok {
+				continue
+			}
+			item := moderationQueueItem{
+				Product:         product,
+				ReportCount:     row.ReportCount,
+				Reasons:         reasons[row.ProductID],
+				FirstReportedAt: row.FirstReportedAt,
+			}
+			if item.Reasons == nil {
+				item.Reasons = map[models.ReportReason]int{}
+			}
+			items = append(items, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":      items,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// GetReports lists reports, newest first, filtered by status, reason and
+// product.
+func (h *ModerationHandler) GetReports(c *gin.Context) {
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	status := c.Query("status")
+	reason := c.Query("reason")
+	productID := c.Query("product_id")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Report{})
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	if productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var reports []models.Report
+	result := query.
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&reports)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports":    reports,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// DismissReport closes a report without acting on the listing.
+func (h *ModerationHandler) DismissReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	// The body is optional
+	var req DismissReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var report models.Report
+	result := repository.DB.First(&report, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if report.Status != models.ReportStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "This report is already resolved"})
+		return
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		result := resolveReports(tx.Where("id = ?", report.ID), models.ReportStatusDismissed, userID.(uint), req.Resolution)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errReportResolved
+		}
+
+		entry := auditEntry(c, models.AuditReportDismiss, models.AuditTargetReport, report.ID)
+		entry.Changes = map[string]interface{}{
+			"productId":  report.ProductID,
+			"status":     gin.H{"before": report.Status, "after": models.ReportStatusDismissed},
+			"resolution": req.Resolution,
+		}
+		return audit.Record(tx, entry)
+	})
+	if errors.Is(err, errReportResolved) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This report is already resolved"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss report"})
+		return
+	}
+
+	repository.DB.First(&report, report.ID)
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// Approve puts a listing pending review, or one a moderator took down, on
+// the market and dismisses its open reports.
+func (h *ModerationHandler) Approve(c *gin.Context) {
+	h.moderate(c, lifecycle.ActionApprove)
+}
+
+// TakeDown hides a listing until a moderator approves it again.
+func (h *ModerationHandler) TakeDown(c *gin.Context) {
+	h.moderate(c, lifecycle.ActionTakeDown)
+}
+
+// Remove removes a listing for breaking the rules.
+func (h *ModerationHandler) Remove(c *gin.Context) {
+	h.moderate(c, lifecycle.ActionRemove)
+}
+
+// moderate applies a moderator's decision to a listing, resolves its open
+// reports and tells the seller.
+func (h *ModerationHandler) moderate(c *gin.Context, action lifecycle.Action) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// The body is optional when approving
+	var req ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" && action != lifecycle.ActionApprove {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return
+	}
+
+	var product models.Product
+	result := repository.DB.First(&product, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	reportStatus := models.ReportStatusActioned
+	resolution := req.Reason
+	if action == lifecycle.ActionApprove {
+		reportStatus = models.ReportStatusDismissed
+		if resolution == "" {
+			resolution = "Listing approved"
+		}
+	}
+
+	var resolved int64
+	before := product.Status
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lifecycle.Apply(tx, &product, action, lifecycle.Options{Reason: req.Reason}); err != nil {
+			return err
+		}
+		result := resolveReports(tx.Where("product_id = ?", product.ID), reportStatus, userID.(uint), resolution)
+		if result.Error != nil {
+			return result.Error
+		}
+		resolved = result.RowsAffected
+
+		entry := auditEntry(c, moderationAuditActions[action], models.AuditTargetProduct, product.ID)
+		entry.Changes = map[string]interface{}{
+			"status":          gin.H{"before": before, "after": product.Status},
+			"reason":          req.Reason,
+			"resolvedReports": resolved,
+		}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		respondLifecycleError(c, err, product.Status, action)
+		return
+	}
+
+	events.Emit(context.Background(), events.ModerationAction, gin.H{
+		"productId": product.ID,
+		"title":     product.Title,
+		"action":    actionLabels[action],
+		"reason":    req.Reason,
+	}, product.UserID)
+
+	// Load updated product with relationships
+	repository.DB.Preload("User").Preload("Category").First(&product, id)
+	attachSellers(&product)
+
+	c.JSON(http.StatusOK, gin.H{"product": product, "resolvedReports": resolved})
+}
+
+// resolveReports closes the open reports matched by scope.
+func resolveReports(scope *gorm.DB, status models.ReportStatus, moderatorID uint, resolution string) *gorm.DB {
+	return scope.Model(&models.Report{}).
+		Where("status = ?", models.ReportStatusOpen).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"resolved_by_id": moderatorID,
+			"resolved_at":    time.Now(),
+			"resolution":     resolution,
+		})
+}
+
+// requiresReview reports whether the seller's listings must be approved by
+// a moderator before going on the market: with REVIEW_NEW_SELLERS, until
+// NEW_SELLER_LISTINGS of their listings have been on the market.
+func requiresReview(userID uint) (bool, error) {
+	if !config.AppConfig.ReviewNewSellers {
+		return false, nil
+	}
+
+	var listed int64
+	err := repository.DB.Unscoped().Model(&models.Product{}).
+		Where("user_id = ? AND listed_at IS NOT NULL", userID).
+		Count(&listed).Error
+	if err != nil {
+		return false, err
+	}
+	return listed < int64(config.AppConfig.NewSellerListings), nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReportProduct(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		userID   uint
+		body     string
+		reported bool
+		wantCode int
+	}{
+		{"spam", "available", 2, `{"reason": "spam"}`, false, http.StatusCreated},
+		{"other with details", "sold", 2, `{"reason": "other", "details": "Stolen photos"}`, false, http.StatusCreated},
+		{"other without details", "available", 2, `{"reason": "other", "details": "  "}`, false, http.StatusBadRequest},
+		{"unknown reason", "available", 2, `{"reason": "ugly"}`, false, http.StatusBadRequest},
+		{"own listing", "available", 1, `{"reason": "spam"}`, false, http.StatusBadRequest},
+		{"draft", "draft", 2, `{"reason": "spam"}`, false, http.StatusNotFound},
+		{"reported twice", "available", 2, `{"reason": "spam"}`, true, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.unique("reports", "product_id", "reporter_id")
+			db.insert("products", map[string]driver.Value{"id": int64(7), "user_id": int64(1), "status": tt.status})
+			if tt.reported {
+				db.insert("reports", map[string]driver.Value{
+					"id": int64(1), "product_id": int64(7), "reporter_id": int64(2), "reason": "fraud", "status": "open",
+				})
+			}
+
+			c, w := newTestContext(http.MethodPost, "/products/7/report", tt.body, tt.userID, gin.Param{Key: "id", Value: "7"})
+			NewModerationHandler().ReportProduct(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			want := 0
+			if tt.reported || tt.wantCode == http.StatusCreated {
+				want = 1
+			}
+			if n := len(db.rows("reports")); n != want {
+				t.Errorf("%d reports stored, want %d", n, want)
+			}
+		})
+	}
+}
+
+func TestModerate(t *testing.T) {
+	type moderate func(h *ModerationHandler, c *gin.Context)
+	approve := func(h *ModerationHandler, c *gin.Context) { h.Approve(c) }
+	takeDown := func(h *ModerationHandler, c *gin.Context) { h.TakeDown(c) }
+	remove := func(h *ModerationHandler, c *gin.Context) { h.Remove(c) }
+
+	tests := []struct {
+		name             string
+		status           string
+		moderated        bool
+		action           moderate
+		body             string
+		wantCode         int
+		wantStatus       string
+		wantReportStatus string
+		wantAudit        string
+	}{
+		{"take down", "available", false, takeDown, `{"reason": "Counterfeit"}`, http.StatusOK, "hidden", "actioned", models.AuditProductTakeDown},
+		{"take down without a reason", "available", false, takeDown, "", http.StatusBadRequest, "available", "open", ""},
+		{"remove", "reserved", false, remove, `{"reason": "Fraud"}`, http.StatusOK, "removed", "actioned", models.AuditProductRemove},
+		{"approve pending review", "pending_review", false, approve, "", http.StatusOK, "available", "dismissed", models.AuditProductApprove},
+		{"approve taken down", "hidden", true, approve, "", http.StatusOK, "available", "dismissed", models.AuditProductApprove},
+		{"approve hidden by the seller", "hidden", false, approve, "", http.StatusConflict, "hidden", "open", ""},
+		{"take down a draft", "draft", false, takeDown, `{"reason": "Spam"}`, http.StatusConflict, "draft", "open", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setListingConfig(t)
+			db := useFakeDB(t)
+			db.insert("categories", map[string]driver.Value{"id": int64(1)})
+			product := map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "category_id": int64(1), "title": "Desk", "status": tt.status,
+			}
+			if tt.moderated {
+				product["moderated_at"] = time.Now()
+			}
+			db.insert("products", product)
+			db.insert("reports", map[string]driver.Value{"id": int64(1), "product_id": int64(7), "reporter_id": int64(2), "status": "open"})
+			db.insert("reports", map[string]driver.Value{"id": int64(2), "product_id": int64(7), "reporter_id": int64(3), "status": "dismissed"})
+			db.insert("reports", map[string]driver.Value{"id": int64(3), "product_id": int64(8), "reporter_id": int64(2), "status": "open"})
+			actions := recordEvents(t, events.ModerationAction)
+
+			c, w := newTestContext(http.MethodPost, "/moderation/products/7", tt.body, 9, gin.Param{Key: "id", Value: "7"})
+			tt.action(NewModerationHandler(), c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			if status := db.rows("products")[0]["status"]; status != tt.wantStatus {
+				t.Errorf("product status = %v, want %s", status, tt.wantStatus)
+			}
+			reports := db.rows("reports")
+			if reports[0]["status"] != tt.wantReportStatus {
+				t.Errorf("open report is now %v, want %s", reports[0]["status"], tt.wantReportStatus)
+			}
+			if reports[1]["status"] != "dismissed" || reports[2]["status"] != "open" {
+				t.Errorf("reports of other states or listings changed: %v, %v", reports[1]["status"], reports[2]["status"])
+			}
+
+			audits := db.rows("audit_entries")
+			emitted := actions()
+			if tt.wantAudit == "" {
+				if len(audits) != 0 || len(emitted) != 0 {
+					t.Errorf("recorded %d audit entries and %d events, want none", len(audits), len(emitted))
+				}
+				return
+			}
+			if len(audits) != 1 || audits[0]["action"] != tt.wantAudit || audits[0]["actor_id"] != int64(9) {
+				t.Errorf("audit entries = %v, want one %s by moderator 9", audits, tt.wantAudit)
+			}
+			if len(emitted) != 1 || emitted[0].UserIDs[0] != 1 {
+				t.Errorf("emitted %v, want one moderation event for the seller", emitted)
+			}
+		})
+	}
+}
+
+func TestDismissReport(t *testing.T) {
+	tests := []struct {
+		status   string
+		wantCode int
+	}{
+		{"open", http.StatusOK},
+		{"dismissed", http.StatusConflict},
+		{"actioned", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		db := useFakeDB(t)
+		db.insert("reports", map[string]driver.Value{"id": int64(1), "product_id": int64(7), "reporter_id": int64(2), "status": tt.status})
+
+		c, w := newTestContext(http.MethodPost, "/moderation/reports/1/dismiss", `{"resolution": "Looks fine"}`, 9, gin.Param{Key: "id", Value: "1"})
+		NewModerationHandler().DismissReport(c)
+
+		if w.Code != tt.wantCode {
+			t.Fatalf("%s report: status = %d, want %d; body %s", tt.status, w.Code, tt.wantCode, w.Body)
+		}
+		report := db.rows("reports")[0]
+		if tt.wantCode == http.StatusOK {
+			if report["status"] != "dismissed" || report["resolved_by_id"] != int64(9) || report["resolution"] != "Looks fine" {
+				t.Errorf("report = %v, want dismissed by 9", report)
+			}
+			if n := len(db.rows("audit_entries")); n != 1 {
+				t.Errorf("%d audit entries, want 1", n)
+			}
+		} else if report["status"] != tt.status {
+			t.Errorf("%s report changed to %v", tt.status, report["status"])
+		}
+	}
+}
+
+func TestRequiresReview(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		listed  int
+		want    bool
+	}{
+		{"review disabled", false, 0, false},
+		{"new seller", true, 0, true},
+		{"almost established", true, 2, true},
+		{"established seller", true, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.AppConfig
+			config.AppConfig = &config.Config{ReviewNewSellers: tt.enabled, NewSellerListings: 3}
+			t.Cleanup(func() { config.AppConfig = previous })
+
+			db := useFakeDB(t)
+			for i := 0; i < tt.listed; i++ {
+				db.insert("products", map[string]driver.Value{"id": int64(i + 1), "user_id": int64(1), "listed_at": time.Now()})
+			}
+			db.insert("products", map[string]driver.Value{"id": int64(10), "user_id": int64(1), "listed_at": nil})
+			db.insert("products", map[string]driver.Value{"id": int64(11), "user_id": int64(2), "listed_at": time.Now()})
+
+			got, err := requiresReview(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("requiresReview = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct{}
+
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{}
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences models.NotificationPreferences `json:"preferences" binding:"required"`
+}
+
+// GetNotifications lists the user's notifications, newest first, with the
+// number still unread.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	unreadOnly := c.Query("unread") == "true"
+	notificationType := c.Query("type")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var unreadCount int64
+	repository.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&unreadCount)
+
+	var notifications []models.Notification
+	result := query.
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unreadCount":   unreadCount,
+		"pagination":    paginationInfo(page, limit, total),
+	})
+}
+
+// MarkRead marks one notification as read. Marking it again is not an error.
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	var notification models.Notification
+	result := repository.DB.Where("id = ? AND user_id = ?", id, userID).First(&notification)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		result = repository.DB.Model(&models.Notification{}).
+			Where("id = ? AND read_at IS NULL", notification.ID).
+			Update("read_at", now)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notification": notification})
+}
+
+// MarkAllRead marks all of the user's notifications as read.
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := repository.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+// GetPreferences returns the user's channels for every notification type.
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	result := repository.DB.Select("id", "notification_preferences").First(&user, userID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": user.NotificationPreferences.Effective()})
+}
+
+// UpdatePreferences changes the channels of the given notification types.
+// Types left out keep their current setting.
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Preferences.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var preferences models.NotificationPreferences
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		preferences, err = updateNotificationPreferences(tx, userID.(uint), req.Preferences)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences.Effective()})
+}
+
+// updateNotificationPreferences merges validated changes into the stored
+// preferences of a user and returns the result. It locks the user's row, so
+// tx must be a transaction.
+func updateNotificationPreferences(tx *gorm.DB, userID uint, changes models.NotificationPreferences) (models.NotificationPreferences, error) {
+	// Serialize concurrent updates so neither loses the other's changes
+	if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := tx.Select("id", "notification_preferences").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	merged := user.NotificationPreferences.Merge(changes)
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).
+		Update("notification_preferences", merged).Error; err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
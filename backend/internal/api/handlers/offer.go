@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/lifecycle"
+	"bech-do-backend/internal/services/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errOfferNotOpen        = errors.New("offer is no longer open")
+	errDuplicateOffer      = errors.New("buyer already has an open offer on this product")
+	errProductNotAvailable = errors.New("product is no longer available")
+)
+
+var openOfferStatuses = []models.OfferStatus{models.OfferStatusPending, models.OfferStatusCountered}
+
+type OfferHandler struct{}
+
+func NewOfferHandler() *OfferHandler {
+	return &OfferHandler{}
+}
+
+type OfferRequest struct {
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+	Message string  `json:"message" binding:"max=1000"`
+}
+
+// CreateOffer makes an offer on a negotiable product that is for sale.
+func (h *OfferHandler) CreateOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req OfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	result := repository.DB.First(&product, productID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	buyerID := userID.(uint)
+	if product.UserID == buyerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot make an offer on your own product"})
+		return
+	}
+	if !product.IsNegotiable {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This product does not accept offers"})
+		return
+	}
+	if product.Status != models.ProductStatusAvailable {
+		c.JSON(http.StatusConflict, gin.H{"error": "This product is no longer available"})
+		return
+	}
+	if req.Amount > product.Price {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Offer cannot exceed the asking price"})
+		return
+	}
+
+	offer := models.Offer{
+		ProductID: product.ID,
+		BuyerID:   buyerID,
+		SellerID:  product.UserID,
+		Amount:    req.Amount,
+		Message:   strings.TrimSpace(req.Message),
+		Status:    models.OfferStatusPending,
+		ExpiresAt: time.Now().Add(config.AppConfig.OfferTTL),
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		blocked, err := usersBlocked(tx, offer.BuyerID, offer.SellerID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return errUserBlocked
+		}
+
+		// Clear out stale offers so they do not count as open
+		if err := expireOffers(tx.Where("product_id = ? AND buyer_id = ?", offer.ProductID, offer.BuyerID)); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&offer).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errDuplicateOffer
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		respondOfferError(c, err)
+		return
+	}
+
+	events.Emit(context.Background(), events.OfferCreated, offer, offer.SellerID)
+
+	c.JSON(http.StatusCreated, gin.H{"offer": offer})
+}
+
+// GetSentOffers lists the offers the user has made as a buyer.
+func (h *OfferHandler) GetSentOffers(c *gin.Context) {
+	h.listOffers(c, "buyer_id")
+}
+
+// GetReceivedOffers lists the offers made on the user's products.
+func (h *OfferHandler) GetReceivedOffers(c *gin.Context) {
+	h.listOffers(c, "seller_id")
+}
+
+func (h *OfferHandler) listOffers(c *gin.Context, party string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	status := c.Query("status")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	if err := expireOffers(repository.DB.Where(party+" = ?", userID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offers"})
+		return
+	}
+
+	query := repository.DB.Model(&models.Offer{}).Where(party+" = ?", userID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if productID, err := strconv.Atoi(c.Query("product_id")); err == nil {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var offers []models.Offer
+	result := query.
+		Preload("Product").
+		Preload("Buyer", participantColumns).
+		Preload("Seller", participantColumns).
+		Order("updated_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&offers)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"offers":     offers,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+func (h *OfferHandler) GetOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	offer, ok := loadOffer(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"offer": offer})
+}
+
+// AcceptOffer accepts a pending offer (seller) or a counter-offer (buyer).
+// The product is reserved for the buyer and any other open offers on it are
+// declined.
+func (h *OfferHandler) AcceptOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	offer, ok := loadOffer(c, userID.(uint))
+	if !ok || !canRespondToOffer(c, offer, userID.(uint)) {
+		return
+	}
+
+	agreed := offer.Amount
+	if offer.Status == models.OfferStatusCountered {
+		agreed = *offer.CounterAmount
+	}
+
+	now := time.Now()
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := transitionOffer(tx, offer, map[string]interface{}{
+			"status":        models.OfferStatusAccepted,
+			"agreed_amount": agreed,
+			"responded_at":  now,
+		}); err != nil {
+			return err
+		}
+
+		// Reserve the product, unless another offer got there first
+		err := lifecycle.Apply(tx, &offer.Product, lifecycle.ActionReserve, lifecycle.Options{BuyerID: &offer.BuyerID})
+		if err == lifecycle.ErrInvalidTransition || err == lifecycle.ErrConflict {
+			return errProductNotAvailable
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Offer{}).
+			Where("product_id = ? AND id <> ? AND status IN ?", offer.ProductID, offer.ID, openOfferStatuses).
+			Updates(map[string]interface{}{
+				"status":       models.OfferStatusDeclined,
+				"responded_at": now,
+			}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			err = errProductNotAvailable
+		}
+		respondOfferError(c, err)
+		return
+	}
+
+	offer.Status = models.OfferStatusAccepted
+	offer.AgreedAmount = &agreed
+	offer.RespondedAt = &now
+	realtime.Publish("offer.updated", offer, offer.BuyerID, offer.SellerID)
+
+	c.JSON(http.StatusOK, gin.H{"offer": offer})
+}
+
+// RejectOffer declines a pending offer (seller) or a counter-offer (buyer).
+func (h *OfferHandler) RejectOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	offer, ok := loadOffer(c, userID.(uint))
+	if !ok || !canRespondToOffer(c, offer, userID.(uint)) {
+		return
+	}
+
+	now := time.Now()
+	err := transitionOffer(repository.DB, offer, map[string]interface{}{
+		"status":       models.OfferStatusDeclined,
+		"responded_at": now,
+	})
+	if err != nil {
+		respondOfferError(c, err)
+		return
+	}
+
+	offer.Status = models.OfferStatusDeclined
+	offer.RespondedAt = &now
+	realtime.Publish("offer.updated", offer, offer.BuyerID, offer.SellerID)
+
+	c.JSON(http.StatusOK, gin.H{"offer": offer})
+}
+
+// CounterOffer lets the seller answer a pending offer with a higher price.
+// The counter gets a fresh expiry for the buyer to respond.
+func (h *OfferHandler) CounterOffer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req OfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	offer, ok := loadOffer(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if offer.SellerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the seller can counter an offer"})
+		return
+	}
+	if offer.Status != models.OfferStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending offers can be countered"})
+		return
+	}
+	if req.Amount <= offer.Amount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Counter-offer must be higher than the offer; accept it instead"})
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.AppConfig.OfferTTL)
+	message := strings.TrimSpace(req.Message)
+	err := transitionOffer(repository.DB, offer, map[string]interface{}{
+		"status":          models.OfferStatusCountered,
+		"counter_amount":  req.Amount,
+		"counter_message": message,
+		"expires_at":      expiresAt,
+		"responded_at":    now,
+	})
+	if err != nil {
+		respondOfferError(c, err)
+		return
+	}
+
+	offer.Status = models.OfferStatusCountered
+	offer.CounterAmount = &req.Amount
+	offer.CounterMessage = message
+	offer.ExpiresAt = expiresAt
+	offer.RespondedAt = &now
+	realtime.Publish("offer.updated", offer, offer.BuyerID, offer.SellerID)
+
+	c.JSON(http.StatusOK, gin.H{"offer": offer})
+}
+
+// loadOffer loads the offer named by the :id parameter. Users who are not
+// party to the offer get a 404. Offers past their expiry are expired first.
+func loadOffer(c *gin.Context, userID uint) (*models.Offer, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return nil, false
+	}
+
+	if err := expireOffers(repository.DB.Where("id = ?", id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	var offer models.Offer
+	result := repository.DB.
+		Where("id = ? AND (buyer_id = ? OR seller_id = ?)", id, userID, userID).
+		Preload("Product").
+		Preload("Buyer", participantColumns).
+		Preload("Seller", participantColumns).
+		First(&offer)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	return &offer, true
+}
+
+// canRespondToOffer checks that it is the user's turn: the seller responds to
+// pending offers and the buyer to counter-offers.
+func canRespondToOffer(c *gin.Context, offer *models.Offer, userID uint) bool {
+	switch {
+	case offer.Status == models.OfferStatusPending && offer.SellerID == userID,
+		offer.Status == models.OfferStatusCountered && offer.BuyerID == userID:
+		return true
+	case !offer.IsOpen():
+		c.JSON(http.StatusConflict, gin.H{"error": "This offer is already " + string(offer.Status)})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Waiting for the other party to respond"})
+	}
+	return false
+}
+
+// transitionOffer applies updates only if the offer is still in the state it
+// was loaded in and has not expired, so concurrent responses cannot both win.
+func transitionOffer(db *gorm.DB, offer *models.Offer, updates map[string]interface{}) error {
+	result := db.Model(&models.Offer{}).
+		Where("id = ? AND status = ? AND expires_at > ?", offer.ID, offer.Status, time.Now()).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errOfferNotOpen
+	}
+	return nil
+}
+
+// ExpireOffers is the job that expires every offer past its deadline. Reads
+// also expire the offers they touch, so this only keeps the table tidy.
+func ExpireOffers(ctx context.Context, job *models.Job) error {
+	return expireOffers(repository.DB.WithContext(ctx))
+}
+
+// expireOffers marks the open offers matched by scope as expired once their
+// expiry has passed.
+func expireOffers(scope *gorm.DB) error {
+	return scope.Model(&models.Offer{}).
+		Where("status IN ? AND expires_at <= ?", openOfferStatuses, time.Now()).
+		Update("status", models.OfferStatusExpired).Error
+}
+
+func respondOfferError(c *gin.Context, err error) {
+	switch err {
+	case errUserBlocked:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot make offers to this user"})
+	case errDuplicateOffer:
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have an open offer on this product"})
+	case errOfferNotOpen:
+		c.JSON(http.StatusConflict, gin.H{"error": "This offer is no longer open"})
+	case errProductNotAvailable:
+		c.JSON(http.StatusConflict, gin.H{"error": "This product is no longer available"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update offer"})
+	}
+}
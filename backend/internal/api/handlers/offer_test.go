@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setOfferConfig(t *testing.T) {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{OfferTTL: 48 * time.Hour, ListingMaxRenewals: 3}
+	t.Cleanup(func() { config.AppConfig = previous })
+}
+
+func TestCreateOffer(t *testing.T) {
+	setOfferConfig(t)
+
+	tests := []struct {
+		name       string
+		buyerID    uint
+		amount     string
+		negotiable bool
+		status     string
+		blocked    bool
+		wantCode   int
+	}{
+		{"offer below the asking price", 2, "800", true, "available", false, http.StatusCreated},
+		{"offer at the asking price", 2, "1000", true, "available", false, http.StatusCreated},
+		{"own product", 1, "800", true, "available", false, http.StatusBadRequest},
+		{"not negotiable", 2, "800", false, "available", false, http.StatusBadRequest},
+		{"reserved product", 2, "800", true, "reserved", false, http.StatusConflict},
+		{"above the asking price", 2, "1200", true, "available", false, http.StatusBadRequest},
+		{"zero", 2, "0", true, "available", false, http.StatusBadRequest},
+		{"blocked by the seller", 2, "800", true, "available", true, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("products", map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "price": 1000.0, "is_negotiable": tt.negotiable, "status": tt.status,
+			})
+			if tt.blocked {
+				db.insert("user_blocks", map[string]driver.Value{"id": int64(1), "blocker_id": int64(1), "blocked_id": int64(2)})
+			}
+			created := recordEvents(t, events.OfferCreated)
+
+			c, w := newTestContext(http.MethodPost, "/products/7/offers", `{"amount": `+tt.amount+`}`, tt.buyerID,
+				gin.Param{Key: "id", Value: "7"})
+			NewOfferHandler().CreateOffer(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			offers := db.rows("offers")
+			if tt.wantCode != http.StatusCreated {
+				if len(offers) != 0 || len(created()) != 0 {
+					t.Errorf("stored %d offers and emitted %d events, want none", len(offers), len(created()))
+				}
+				return
+			}
+			if len(offers) != 1 || offers[0]["status"] != "pending" || offers[0]["seller_id"] != int64(1) {
+				t.Fatalf("stored offers = %v, want one pending offer to seller 1", offers)
+			}
+			if expiresAt, _ := offers[0]["expires_at"].(time.Time); time.Until(expiresAt) < 47*time.Hour {
+				t.Errorf("offer expires at %v, want OfferTTL from now", expiresAt)
+			}
+			if emitted := created(); len(emitted) != 1 || len(emitted[0].UserIDs) != 1 || emitted[0].UserIDs[0] != 1 {
+				t.Errorf("emitted %v, want one offer event for the seller", emitted)
+			}
+		})
+	}
+}
+
+func TestRespondToOffer(t *testing.T) {
+	setOfferConfig(t)
+
+	type respond func(h *OfferHandler, c *gin.Context)
+	accept := func(h *OfferHandler, c *gin.Context) { h.AcceptOffer(c) }
+	reject := func(h *OfferHandler, c *gin.Context) { h.RejectOffer(c) }
+	counter := func(h *OfferHandler, c *gin.Context) { h.CounterOffer(c) }
+
+	tests := []struct {
+		name        string
+		status      string
+		expired     bool
+		userID      uint
+		action      respond
+		body        string
+		wantCode    int
+		wantStatus  string
+		wantAgreed  driver.Value
+		wantProduct string
+	}{
+		{"seller accepts", "pending", false, 1, accept, "", http.StatusOK, "accepted", 800.0, "reserved"},
+		{"buyer accepts own offer", "pending", false, 2, accept, "", http.StatusForbidden, "pending", nil, "available"},
+		{"buyer accepts counter", "countered", false, 2, accept, "", http.StatusOK, "accepted", 900.0, "reserved"},
+		{"seller accepts own counter", "countered", false, 1, accept, "", http.StatusForbidden, "countered", nil, "available"},
+		{"seller rejects", "pending", false, 1, reject, "", http.StatusOK, "declined", nil, "available"},
+		{"buyer rejects counter", "countered", false, 2, reject, "", http.StatusOK, "declined", nil, "available"},
+		{"seller counters higher", "pending", false, 1, counter, `{"amount": 950}`, http.StatusOK, "countered", nil, "available"},
+		{"seller counters lower", "pending", false, 1, counter, `{"amount": 700}`, http.StatusBadRequest, "pending", nil, "available"},
+		{"buyer counters", "pending", false, 2, counter, `{"amount": 950}`, http.StatusForbidden, "pending", nil, "available"},
+		{"counter on a counter", "countered", false, 1, counter, `{"amount": 950}`, http.StatusConflict, "countered", nil, "available"},
+		{"already declined", "declined", false, 1, accept, "", http.StatusConflict, "declined", nil, "available"},
+		{"expired", "pending", true, 1, accept, "", http.StatusConflict, "expired", nil, "available"},
+		{"stranger", "pending", false, 3, accept, "", http.StatusNotFound, "pending", nil, "available"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("products", map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "category_id": int64(1), "price": 1000.0, "status": "available",
+				"expires_at": time.Now().Add(24 * time.Hour),
+			})
+			expiresAt := time.Now().Add(time.Hour)
+			if tt.expired {
+				expiresAt = time.Now().Add(-time.Hour)
+			}
+			offer := map[string]driver.Value{
+				"id": int64(5), "product_id": int64(7), "buyer_id": int64(2), "seller_id": int64(1),
+				"amount": 800.0, "status": tt.status, "expires_at": expiresAt,
+			}
+			if tt.status == "countered" {
+				offer["counter_amount"] = 900.0
+			}
+			db.insert("offers", offer)
+
+			c, w := newTestContext(http.MethodPost, "/offers/5", tt.body, tt.userID, gin.Param{Key: "id", Value: "5"})
+			tt.action(NewOfferHandler(), c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			stored := db.rows("offers")[0]
+			if stored["status"] != tt.wantStatus {
+				t.Errorf("offer status = %v, want %s", stored["status"], tt.wantStatus)
+			}
+			if stored["agreed_amount"] != tt.wantAgreed {
+				t.Errorf("agreed amount = %v, want %v", stored["agreed_amount"], tt.wantAgreed)
+			}
+			product := db.rows("products")[0]
+			if product["status"] != tt.wantProduct {
+				t.Errorf("product status = %v, want %s", product["status"], tt.wantProduct)
+			}
+			if tt.wantProduct == "reserved" && product["reserved_for_id"] != int64(2) {
+				t.Errorf("product reserved for %v, want the buyer", product["reserved_for_id"])
+			}
+			if tt.wantStatus == "countered" && tt.status == "pending" && stored["counter_amount"] != 950.0 {
+				t.Errorf("counter amount = %v, want 950", stored["counter_amount"])
+			}
+		})
+	}
+}
+
+func TestAcceptOfferDeclinesOtherOpenOffers(t *testing.T) {
+	setOfferConfig(t)
+	db := useFakeDB(t)
+	db.insert("products", map[string]driver.Value{
+		"id": int64(7), "user_id": int64(1), "category_id": int64(1), "price": 1000.0, "status": "available",
+		"expires_at": time.Now().Add(24 * time.Hour),
+	})
+	for i, status := range []models.OfferStatus{models.OfferStatusPending, models.OfferStatusPending, models.OfferStatusCountered, models.OfferStatusDeclined} {
+		db.insert("offers", map[string]driver.Value{
+			"id": int64(i + 1), "product_id": int64(7), "buyer_id": int64(i + 2), "seller_id": int64(1),
+			"amount": 800.0, "status": string(status), "expires_at": time.Now().Add(time.Hour),
+		})
+	}
+
+	c, w := newTestContext(http.MethodPost, "/offers/1/accept", "", 1, gin.Param{Key: "id", Value: "1"})
+	NewOfferHandler().AcceptOffer(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d; body %s", w.Code, w.Body)
+	}
+	want := []string{"accepted", "declined", "declined", "declined"}
+	for i, offer := range db.rows("offers") {
+		if offer["status"] != want[i] {
+			t.Errorf("offer %v status = %v, want %s", offer["id"], offer["status"], want[i])
+		}
+	}
+}
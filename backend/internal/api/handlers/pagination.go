@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageSize caps the limit query parameter of paginated lists.
+const maxPageSize = 100
+
+// pageParams reads the page and limit query parameters. Pages start at 1,
+// and limits outside 1..maxPageSize are clamped, defaulting to defaultLimit
+// when missing or invalid.
+func pageParams(c *gin.Context, defaultLimit int) (page, limit int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	switch {
+	case err != nil:
+		limit = defaultLimit
+	case limit < 1:
+		limit = 1
+	case limit > maxPageSize:
+		limit = maxPageSize
+	}
+	return page, limit
+}
+
+// paginationInfo is the pagination block of a paginated list response.
+func paginationInfo(page, limit int, total int64) gin.H {
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return gin.H{
+		"currentPage": page,
+		"totalPages":  totalPages,
+		"totalCount":  total,
+		"hasNext":     page < totalPages,
+		"hasPrev":     page > 1,
+	}
+}
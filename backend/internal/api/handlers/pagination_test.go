@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPageParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query             string
+		wantPage, wantLim int
+	}{
+		{"", 1, 20},
+		{"?page=3&limit=50", 3, 50},
+		{"?page=0&limit=0", 1, 1},
+		{"?page=-2&limit=-5", 1, 1},
+		{"?limit=1000", 1, maxPageSize},
+		{"?page=abc&limit=xyz", 1, 20},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/items"+tt.query, nil)
+
+		page, limit := pageParams(c, 20)
+		if page != tt.wantPage || limit != tt.wantLim {
+			t.Errorf("pageParams(%q) = %d, %d; want %d, %d", tt.query, page, limit, tt.wantPage, tt.wantLim)
+		}
+	}
+}
+
+func TestPaginationInfo(t *testing.T) {
+	tests := []struct {
+		page, limit    int
+		total          int64
+		wantTotalPages int
+		wantNext       bool
+		wantPrev       bool
+	}{
+		{1, 20, 0, 0, false, false},
+		{1, 20, 20, 1, false, false},
+		{1, 20, 21, 2, true, false},
+		{2, 20, 21, 2, false, true},
+		{3, 1, 5, 5, true, true},
+	}
+	for _, tt := range tests {
+		info := paginationInfo(tt.page, tt.limit, tt.total)
+		if info["totalPages"] != tt.wantTotalPages || info["hasNext"] != tt.wantNext || info["hasPrev"] != tt.wantPrev {
+			t.Errorf("paginationInfo(%d, %d, %d) = %v", tt.page, tt.limit, tt.total, info)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/audit"
+	"bech-do-backend/internal/services/jobs"
+	"bech-do-backend/internal/services/mailer"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const passwordResetRequestDelay = time.Minute
+
+var errInvalidResetToken = errors.New("invalid or expired reset token")
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The lookup and email are done in the background so that the response,
+	// including its timing, is the same whether or not the account exists
+	go h.issuePasswordReset(normalizeEmail(req.Email), c.ClientIP())
+
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for this email, a password reset link has been sent"})
+}
+
+func (h *AuthHandler) issuePasswordReset(email, ipAddress string) {
+	var user models.User
+	result := repository.DB.Where("LOWER(email) = ? AND is_active = ?", email, true).First(&user)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			log.Printf("Password reset lookup failed: %v", result.Error)
+		}
+		return
+	}
+
+	// Throttle repeated requests for the same account
+	var recent int64
+	repository.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-passwordResetRequestDelay)).
+		Count(&recent)
+	if recent > 0 {
+		return
+	}
+
+	// The job issues the token, so only its hash is ever stored; the key
+	// also folds requests within the same minute into one email
+	payload := passwordResetJob{UserID: user.ID, IPAddress: ipAddress}
+	key := fmt.Sprintf("password_reset:%d:%d", user.ID, time.Now().Unix()/int64(passwordResetRequestDelay.Seconds()))
+	if err := jobs.Enqueue(repository.DB, jobs.KindSendPasswordReset, payload, jobs.Options{Key: key}); err != nil {
+		log.Printf("Failed to queue password reset email to user %d: %v", user.ID, err)
+	}
+}
+
+// passwordResetJob is the payload of a password reset email job. It names
+// the user rather than carrying the link, so the jobs table never holds a
+// usable token.
+type passwordResetJob struct {
+	UserID    uint   `json:"userId"`
+	IPAddress string `json:"ipAddress"`
+}
+
+// SendPasswordReset returns the job handler that issues a password reset
+// token and emails its link. Every attempt issues a fresh token, which
+// invalidates the ones issued before it.
+func SendPasswordReset(m mailer.Mailer) jobs.Handler {
+	return func(ctx context.Context, job *models.Job) error {
+		var payload passwordResetJob
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+
+		var user models.User
+		result := repository.DB.WithContext(ctx).Where("id = ? AND is_active = ?", payload.UserID, true).First(&user)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return result.Error
+		}
+
+		token, tokenHash, err := generateOpaqueToken()
+		if err != nil {
+			return err
+		}
+
+		err = repository.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Only the most recently issued link stays valid
+			if err := tx.Model(&models.PasswordResetToken{}).
+				Where("user_id = ? AND used_at IS NULL", user.ID).
+				Update("used_at", time.Now()).Error; err != nil {
+				return err
+			}
+
+			return tx.Create(&models.PasswordResetToken{
+				UserID:    user.ID,
+				TokenHash: tokenHash,
+				IPAddress: payload.IPAddress,
+				ExpiresAt: time.Now().Add(config.AppConfig.PasswordResetTTL),
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+
+		link := config.AppConfig.FrontendURL + "/reset-password?token=" + url.QueryEscape(token)
+		return m.Send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your Bech-Do password",
+			Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s and can only be used once.\n\nIf you did not request a password reset, you can ignore this email.\n",
+				user.FirstName, link, config.AppConfig.PasswordResetTTL),
+		})
+	}
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		var resetToken models.PasswordResetToken
+		result := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(req.Token), time.Now()).
+			First(&resetToken)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return errInvalidResetToken
+			}
+			return result.Error
+		}
+
+		// Consume the token; a concurrent reset using the same token loses here
+		result = tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidResetToken
+		}
+
+		result = tx.Model(&models.User{}).
+			Where("id = ? AND is_active = ?", resetToken.UserID, true).
+			Update("password", string(hashedPassword))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidResetToken
+		}
+
+		// Sign out everywhere, including whoever may know the old password
+		if err := revokeUserSessions(tx, resetToken.UserID); err != nil {
+			return err
+		}
+
+		// The request is unauthenticated; the token's owner is the actor
+		entry := auditEntry(c, models.AuditPasswordReset, models.AuditTargetUser, resetToken.UserID)
+		entry.ActorID = &resetToken.UserID
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		if err == errInvalidResetToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully. Please log in with your new password"})
+}
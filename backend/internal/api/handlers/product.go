@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"bech-do-backend/internal/api/middleware"
+	"bech-do-backend/internal/config"
 	"bech-do-backend/internal/models"
 	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/audit"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/lifecycle"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,20 +32,25 @@ type CreateProductRequest struct {
 	Images       []string `json:"images" binding:"required,min=1"`
 	Condition    string   `json:"condition" binding:"required"`
 	Location     string   `json:"location" binding:"required"`
+	PinCode      string   `json:"pin_code"`
 	IsNegotiable bool     `json:"is_negotiable"`
 	CategoryID   uint     `json:"category_id" binding:"required"`
+	// Draft saves the listing without publishing it
+	Draft bool `json:"draft"`
 }
 
 type UpdateProductRequest struct {
 	Title        string   `json:"title"`
 	Description  string   `json:"description"`
-	Price        float64  `json:"price" binding:"min=0"`
+	Price        *float64 `json:"price" binding:"omitempty,min=0"`
 	Images       []string `json:"images"`
 	Condition    string   `json:"condition"`
 	Location     string   `json:"location"`
+	PinCode      string   `json:"pin_code"`
 	IsNegotiable bool     `json:"is_negotiable"`
 	CategoryID   uint     `json:"category_id"`
-	Status       string   `json:"status"`
+	// Status is rejected; use the lifecycle actions instead
+	Status string `json:"status"`
 }
 
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
@@ -54,6 +66,23 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	var seller models.User
+	if err := repository.DB.First(&seller, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Unverified users may browse but not sell
+	if config.AppConfig.RequireVerifiedSeller && !seller.IsVerified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before listing products"})
+		return
+	}
+
+	// Locate the listing by its own pincode; the seller's home is never used,
+	// so listings without one are left out of near-me search
+	pinCode := strings.TrimSpace(req.PinCode)
+	latitude, longitude := productCoordinates(pinCode)
+
 	// Verify category exists
 	var category models.Category
 	result := repository.DB.First(&category, req.CategoryID)
@@ -62,20 +91,58 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	// Create product
+	// Only accept images uploaded through our storage by this user
 	userIDUint := userID.(uint)
+	imageVariants, err := resolveImages(userIDUint, req.Images, nil)
+	if err != nil {
+		respondImageRefError(c, err)
+		return
+	}
+
+	review, err := requiresReview(userIDUint)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		return
+	}
+
+	// New sellers' listings may wait for a moderator before going live
+	status := models.ProductStatusAvailable
+	var listedAt, expiresAt *time.Time
+	if req.Draft {
+		status = models.ProductStatusDraft
+	} else if review {
+		status = models.ProductStatusPendingReview
+	} else {
+		now := time.Now()
+		expiry, err := lifecycle.ExpiresAt(repository.DB, req.CategoryID, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+			return
+		}
+		listedAt = &now
+		expiresAt = &expiry
+	}
+
+	// Create product
 	product := models.Product{
-		Title:        req.Title,
-		Description:  req.Description,
-		Price:        req.Price,
-		Images:       req.Images,
-		Condition:    req.Condition,
-		Location:     req.Location,
-		IsNegotiable: req.IsNegotiable,
-		Status:       models.ProductStatusAvailable,
-		UserID:       userIDUint,
-		CategoryID:   req.CategoryID,
-		Views:        0,
+		Title:         req.Title,
+		Description:   req.Description,
+		Price:         req.Price,
+		Images:        req.Images,
+		ImageVariants: imageVariants,
+		Condition:     req.Condition,
+		Location:      req.Location,
+		PinCode:       pinCode,
+		Latitude:      latitude,
+		Longitude:     longitude,
+		IsNegotiable:  req.IsNegotiable,
+		Status:        status,
+		IsActive:      status.IsListed(),
+		ListedAt:      listedAt,
+		ExpiresAt:     expiresAt,
+		UserID:        userIDUint,
+		CategoryID:    req.CategoryID,
+		Views:         0,
 	}
 
 	if err := repository.DB.Create(&product).Error; err != nil {
@@ -85,61 +152,48 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 
 	// Load relationships
 	repository.DB.Preload("User").Preload("Category").First(&product, product.ID)
+	attachSellers(&product)
 
 	c.JSON(http.StatusCreated, gin.H{"product": product})
 }
 
 func (h *ProductHandler) GetProducts(c *gin.Context) {
 	// Query parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
-	search := c.Query("search")
-	category := c.Query("category")
-	condition := c.Query("condition")
-	minPrice, _ := strconv.ParseFloat(c.Query("min_price"), 64)
-	maxPrice, _ := strconv.ParseFloat(c.Query("max_price"), 64)
-	sortBy := c.DefaultQuery("sort", "created_at")
+	page, limit := pageParams(c, 12)
+	sortBy := c.Query("sort")
 	order := c.DefaultQuery("order", "desc")
+	filters, err := parseProductFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	facets, err := parseFacets(c.Query("facets"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Calculate offset
 	offset := (page - 1) * limit
 
 	// Build query
-	query := repository.DB.Model(&models.Product{}).
-		Where("status = ?", models.ProductStatusAvailable).
+	query := filteredProducts(filters).
 		Preload("User").
 		Preload("Category")
 
-	// Apply filters
-	if search != "" {
-		searchTerm := "%" + strings.ToLower(search) + "%"
-		query = query.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
-	}
-
-	if category != "" {
-		query = query.Joins("JOIN categories ON products.category_id = categories.id").
-			Where("categories.name = ?", category)
-	}
-
-	if condition != "" {
-		query = query.Where("condition = ?", condition)
-	}
-
-	if minPrice > 0 {
-		query = query.Where("price >= ?", minPrice)
-	}
-
-	if maxPrice > 0 {
-		query = query.Where("price <= ?", maxPrice)
-	}
-
 	// Count total results
 	var total int64
 	query.Count(&total)
 
+	// Add relevance, highlights and distance when searching
+	if columns, args := productColumns(filters); columns != "" {
+		query = query.Select(columns, args...)
+	}
+
 	// Apply sorting and pagination
 	var products []models.Product
-	result := query.Order(sortBy + " " + order).
+	result := query.Order(productOrder(sortBy, order, filters)).
 		Limit(limit).
 		Offset(offset).
 		Find(&products)
@@ -149,21 +203,39 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		return
 	}
 
-	// Calculate pagination info
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	hasNext := page < totalPages
-	hasPrev := page > 1
+	if filters.Search != "" {
+		applyHighlights(products)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"products": products,
-		"pagination": gin.H{
-			"currentPage": page,
-			"totalPages":  totalPages,
-			"totalCount":  total,
-			"hasNext":     hasNext,
-			"hasPrev":     hasPrev,
-		},
-	})
+	// Embed sellers through their public profile only
+	productRefs := make([]*models.Product, len(products))
+	for i := range products {
+		productRefs[i] = &products[i]
+	}
+	if err := attachSellers(productRefs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sellers"})
+		return
+	}
+	if err := markFavorited(c, productRefs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch favorites"})
+		return
+	}
+
+	response := gin.H{
+		"products":   products,
+		"pagination": paginationInfo(page, limit, total),
+	}
+
+	if len(facets) > 0 {
+		counts, err := computeFacets(filters, facets)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute facets"})
+			return
+		}
+		response["facets"] = counts
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *ProductHandler) GetProduct(c *gin.Context) {
@@ -184,8 +256,23 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	// Increment view count
-	repository.DB.Model(&product).Update("views", gorm.Expr("views + 1"))
+	// Drafts, hidden and removed listings are only visible to the seller
+	if !product.Status.IsPublic() && !canViewHiddenProduct(c, &product) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	if err := attachSellers(&product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err := markFavorited(c, &product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Count the view; counts are written in batches
+	productViews.add(product.ID)
 	product.Views++
 
 	c.JSON(http.StatusOK, gin.H{"product": product})
@@ -210,6 +297,11 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	if req.Status != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status cannot be updated directly; use the publish, reserve, mark-sold, relist and hide actions"})
+		return
+	}
+
 	// Find product and verify ownership
 	var product models.Product
 	result := repository.DB.First(&product, id)
@@ -228,6 +320,11 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	if product.Status == models.ProductStatusRemoved {
+		c.JSON(http.StatusConflict, gin.H{"error": "This product was removed by a moderator"})
+		return
+	}
+
 	// Update product fields
 	updates := make(map[string]interface{})
 	if req.Title != "" {
@@ -236,11 +333,20 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Description != "" {
 		updates["description"] = req.Description
 	}
-	if req.Price >= 0 {
-		updates["price"] = req.Price
+	if req.Price != nil {
+		updates["price"] = *req.Price
 	}
 	if len(req.Images) > 0 {
-		updates["images"] = req.Images
+		imageVariants, err := resolveImages(userIDUint, req.Images, productImageVariants(product))
+		if err != nil {
+			respondImageRefError(c, err)
+			return
+		}
+		// Map updates bypass the JSON serializer, so encode the columns here
+		imagesJSON, _ := json.Marshal(req.Images)
+		variantsJSON, _ := json.Marshal(imageVariants)
+		updates["images"] = string(imagesJSON)
+		updates["image_variants"] = string(variantsJSON)
 	}
 	if req.Condition != "" {
 		updates["condition"] = req.Condition
@@ -248,13 +354,20 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Location != "" {
 		updates["location"] = req.Location
 	}
+	if req.PinCode != "" {
+		latitude, longitude := productCoordinates(req.PinCode)
+		updates["pin_code"] = strings.TrimSpace(req.PinCode)
+		updates["latitude"] = latitude
+		updates["longitude"] = longitude
+	}
 	updates["is_negotiable"] = req.IsNegotiable
 	if req.CategoryID > 0 {
 		updates["category_id"] = req.CategoryID
 	}
-	if req.Status != "" {
-		updates["status"] = req.Status
-	}
+
+	// Updates writes the new values back into product, so keep the old
+	// price to tell favoriters about changes
+	oldPrice := product.Price
 
 	// Update product
 	result = repository.DB.Model(&product).Updates(updates)
@@ -265,6 +378,15 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 
 	// Load updated product with relationships
 	repository.DB.Preload("User").Preload("Category").First(&product, id)
+	attachSellers(&product)
+	markFavorited(c, &product)
+
+	if product.Price != oldPrice && product.Status.IsPublic() {
+		notifyFavoriters(events.FavoritePriceChanged, &product, gin.H{
+			"oldPrice": oldPrice,
+			"newPrice": product.Price,
+		})
+	}
 
 	c.JSON(http.StatusOK, gin.H{"product": product})
 }
@@ -295,15 +417,28 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	}
 
 	userIDUint := userID.(uint)
-	userRole, _ := c.Get("user_role")
-	if product.UserID != userIDUint && userRole != string(models.UserRoleAdmin) {
+	if product.UserID != userIDUint && !middleware.HasPermission(c, models.PermissionProductsManage) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own products"})
 		return
 	}
 
 	// Soft delete product
-	result = repository.DB.Delete(&product)
-	if result.Error != nil {
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+
+		entry := auditEntry(c, models.AuditProductDelete, models.AuditTargetProduct, product.ID)
+		entry.Changes = audit.Diff(map[string]interface{}{
+			"title":      product.Title,
+			"price":      product.Price,
+			"status":     product.Status,
+			"sellerId":   product.UserID,
+			"categoryId": product.CategoryID,
+		}, nil)
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
 	}
@@ -319,8 +454,7 @@ func (h *ProductHandler) GetMyProducts(c *gin.Context) {
 	}
 
 	// Query parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	page, limit := pageParams(c, 12)
 	status := c.Query("status")
 
 	// Calculate offset
@@ -351,19 +485,19 @@ func (h *ProductHandler) GetMyProducts(c *gin.Context) {
 		return
 	}
 
-	// Calculate pagination info
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	hasNext := page < totalPages
-	hasPrev := page > 1
-
 	c.JSON(http.StatusOK, gin.H{
-		"products": products,
-		"pagination": gin.H{
-			"currentPage": page,
-			"totalPages":  totalPages,
-			"totalCount":  total,
-			"hasNext":     hasNext,
-			"hasPrev":     hasPrev,
-		},
+		"products":   products,
+		"pagination": paginationInfo(page, limit, total),
 	})
 }
+
+// canViewHiddenProduct reports whether the requester is the seller or staff
+// allowed to view any product. It relies on OptionalAuthMiddleware to
+// identify the requester.
+func canViewHiddenProduct(c *gin.Context, product *models.Product) bool {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return false
+	}
+	return userID.(uint) == product.UserID || middleware.HasPermission(c, models.PermissionProductsView)
+}
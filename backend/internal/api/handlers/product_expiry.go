@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/lifecycle"
+
+	"gorm.io/gorm"
+)
+
+// maxExpiriesPerRun bounds the listings one sweep warns about or expires;
+// the next sweep picks up the rest.
+const maxExpiriesPerRun = 500
+
+// ExpireListings is the job that warns sellers about listings expiring
+// within LISTING_EXPIRY_WARNING and expires the listings whose time is up.
+// Reserved listings are left alone until the reservation is released.
+func ExpireListings(ctx context.Context, job *models.Job) error {
+	now := time.Now()
+	if err := warnExpiringListings(ctx, now); err != nil {
+		return err
+	}
+	return expireListings(ctx, now)
+}
+
+// warnExpiringListings sends each listing's warning once per listing
+// period. Renewing clears the mark, so the next period gets its own.
+func warnExpiringListings(ctx context.Context, now time.Time) error {
+	var products []models.Product
+	err := repository.DB.WithContext(ctx).
+		Select("id", "title", "user_id", "expires_at").
+		Where("status = ? AND expiry_warned_at IS NULL AND expires_at > ? AND expires_at <= ?",
+			models.ProductStatusAvailable, now, now.Add(config.AppConfig.ListingExpiryWarning)).
+		Order("expires_at, id").
+		Limit(maxExpiriesPerRun).
+		Find(&products).Error
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		// Claim the warning so overlapping sweeps send it once
+		result := repository.DB.WithContext(ctx).Model(&models.Product{}).
+			Where("id = ? AND expiry_warned_at IS NULL AND expires_at = ?", product.ID, product.ExpiresAt).
+			Update("expiry_warned_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		events.Emit(ctx, events.ListingExpiring, map[string]interface{}{
+			"productId": product.ID,
+			"title":     product.Title,
+			"expiresAt": product.ExpiresAt,
+		}, product.UserID)
+	}
+	return nil
+}
+
+func expireListings(ctx context.Context, now time.Time) error {
+	var products []models.Product
+	err := repository.DB.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", models.ProductStatusAvailable, now).
+		Order("expires_at, id").
+		Limit(maxExpiriesPerRun).
+		Find(&products).Error
+	if err != nil {
+		return err
+	}
+
+	expired := 0
+	for i := range products {
+		err := repository.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return lifecycle.Apply(tx, &products[i], lifecycle.ActionExpire, lifecycle.Options{})
+		})
+		switch err {
+		case nil:
+			expired++
+		case lifecycle.ErrConflict:
+			// Sold, reserved or renewed in the meantime
+		default:
+			return err
+		}
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d listings", expired)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setListingConfig(t *testing.T) {
+	t.Helper()
+	previous := config.AppConfig
+	config.AppConfig = &config.Config{
+		ListingTTL:           30 * 24 * time.Hour,
+		ListingExpiryWarning: 3 * 24 * time.Hour,
+		ListingMaxRenewals:   2,
+	}
+	t.Cleanup(func() { config.AppConfig = previous })
+}
+
+func TestExpireListings(t *testing.T) {
+	setListingConfig(t)
+	now := time.Now()
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name       string
+		status     string
+		expiresAt  time.Time
+		warnedAt   driver.Value
+		wantStatus string
+		wantWarned bool
+	}{
+		{"past its period", "available", now.Add(-time.Hour), nil, "expired", false},
+		{"within the warning", "available", now.Add(2 * day), nil, "available", true},
+		{"already warned", "available", now.Add(2 * day), now.Add(-day), "available", false},
+		{"before the warning", "available", now.Add(10 * day), nil, "available", false},
+		{"reserved past its period", "reserved", now.Add(-time.Hour), nil, "reserved", false},
+		{"sold past its period", "sold", now.Add(-time.Hour), nil, "sold", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("products", map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "title": "Desk", "status": tt.status,
+				"expires_at": tt.expiresAt, "expiry_warned_at": tt.warnedAt,
+			})
+			warnings := recordEvents(t, events.ListingExpiring)
+
+			if err := ExpireListings(context.Background(), nil); err != nil {
+				t.Fatal(err)
+			}
+
+			product := db.rows("products")[0]
+			if product["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", product["status"], tt.wantStatus)
+			}
+			emitted := warnings()
+			if got := len(emitted) == 1; got != tt.wantWarned {
+				t.Errorf("emitted %d expiry warnings, want one: %v", len(emitted), tt.wantWarned)
+			}
+			if tt.wantWarned {
+				if product["expiry_warned_at"] == nil {
+					t.Error("expiry_warned_at not set, so the warning would be sent again")
+				}
+				if emitted[0].UserIDs[0] != 1 {
+					t.Errorf("warned %v, want the seller", emitted[0].UserIDs)
+				}
+			}
+
+			// A second sweep changes nothing
+			if err := ExpireListings(context.Background(), nil); err != nil {
+				t.Fatal(err)
+			}
+			if n := len(warnings()); n != len(emitted) {
+				t.Errorf("second sweep sent %d more warnings", n-len(emitted))
+			}
+		})
+	}
+}
+
+func TestRenewListing(t *testing.T) {
+	setListingConfig(t)
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		status      string
+		renewals    int64
+		listingDays driver.Value
+		wantCode    int
+		wantPeriod  time.Duration
+	}{
+		{"live listing", "available", 0, nil, http.StatusOK, 30 * 24 * time.Hour},
+		{"expired listing", "expired", 1, nil, http.StatusOK, 30 * 24 * time.Hour},
+		{"category period", "available", 0, int64(7), http.StatusOK, 7 * 24 * time.Hour},
+		{"renewal limit", "available", 2, nil, http.StatusConflict, 0},
+		{"sold listing", "sold", 0, nil, http.StatusConflict, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("categories", map[string]driver.Value{"id": int64(1), "listing_days": tt.listingDays})
+			db.insert("products", map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "category_id": int64(1), "status": tt.status,
+				"renewal_count": tt.renewals, "expires_at": now.Add(time.Hour), "expiry_warned_at": now,
+			})
+
+			c, w := newTestContext(http.MethodPost, "/products/7/renew", "", 1, gin.Param{Key: "id", Value: "7"})
+			NewProductHandler().Renew(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			product := db.rows("products")[0]
+			if tt.wantCode != http.StatusOK {
+				if product["renewal_count"] != tt.renewals {
+					t.Errorf("renewal_count = %v, want it unchanged", product["renewal_count"])
+				}
+				return
+			}
+
+			if product["status"] != "available" || product["renewal_count"] != tt.renewals+1 {
+				t.Errorf("status %v with %v renewals, want available with %d", product["status"], product["renewal_count"], tt.renewals+1)
+			}
+			expiresAt, _ := product["expires_at"].(time.Time)
+			if period := expiresAt.Sub(now); period < tt.wantPeriod-time.Minute || period > tt.wantPeriod+time.Minute {
+				t.Errorf("renewed for %v, want %v", period, tt.wantPeriod)
+			}
+			if product["expiry_warned_at"] != nil {
+				t.Error("expiry_warned_at kept, so the new period would get no warning")
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"bech-do-backend/internal/models"
+)
+
+const locationFacetLimit = 20
+
+// FacetBucket is one value of a facet with the number of matching products.
+type FacetBucket struct {
+	Value string   `json:"value"`
+	Count int64    `json:"count"`
+	ID    uint     `json:"id,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+type priceBucket struct {
+	label string
+	min   float64
+	max   float64 // 0 means unbounded
+}
+
+// priceBuckets are the price ranges (in rupees) reported by the
+// price_bucket facet.
+var priceBuckets = []priceBucket{
+	{label: "0-500", min: 0, max: 500},
+	{label: "500-1000", min: 500, max: 1000},
+	{label: "1000-5000", min: 1000, max: 5000},
+	{label: "5000-10000", min: 5000, max: 10000},
+	{label: "10000-50000", min: 10000, max: 50000},
+	{label: "50000+", min: 50000},
+}
+
+var facetFuncs = map[string]func(models.ProductFilters) ([]FacetBucket, error){
+	"category":     categoryFacet,
+	"condition":    conditionFacet,
+	"price_bucket": priceBucketFacet,
+	"location":     locationFacet,
+}
+
+// parseFacets parses the comma-separated facets parameter.
+func parseFacets(param string) ([]string, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var facets []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := facetFuncs[name]; !ok {
+			return nil, fmt.Errorf("unknown facet %q", name)
+		}
+		seen[name] = true
+		facets = append(facets, name)
+	}
+	return facets, nil
+}
+
+// computeFacets counts products per facet value using the same filters as
+// the listing, so the counts match the current search.
+func computeFacets(filters models.ProductFilters, facets []string) (map[string][]FacetBucket, error) {
+	result := make(map[string][]FacetBucket, len(facets))
+	for _, name := range facets {
+		buckets, err := facetFuncs[name](filters)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = buckets
+	}
+	return result, nil
+}
+
+func categoryFacet(filters models.ProductFilters) ([]FacetBucket, error) {
+	buckets := []FacetBucket{}
+	err := filteredProducts(filters).
+		Select("categories.id AS id, categories.name AS value, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = products.category_id").
+		Group("categories.id, categories.name").
+		Order("count DESC, categories.name").
+		Scan(&buckets).Error
+	return buckets, err
+}
+
+func conditionFacet(filters models.ProductFilters) ([]FacetBucket, error) {
+	buckets := []FacetBucket{}
+	err := filteredProducts(filters).
+		Select("products.condition AS value, COUNT(*) AS count").
+		Group("products.condition").
+		Order("count DESC, products.condition").
+		Scan(&buckets).Error
+	return buckets, err
+}
+
+func locationFacet(filters models.ProductFilters) ([]FacetBucket, error) {
+	buckets := []FacetBucket{}
+	err := filteredProducts(filters).
+		Select("products.location AS value, COUNT(*) AS count").
+		Where("products.location IS NOT NULL AND products.location <> ''").
+		Group("products.location").
+		Order("count DESC, products.location").
+		Limit(locationFacetLimit).
+		Scan(&buckets).Error
+	return buckets, err
+}
+
+func priceBucketFacet(filters models.ProductFilters) ([]FacetBucket, error) {
+	// The bucket bounds are constants, so they are inlined into the CASE
+	var cases strings.Builder
+	cases.WriteString("CASE")
+	for _, bucket := range priceBuckets {
+		if bucket.max > 0 {
+			fmt.Fprintf(&cases, " WHEN products.price < %g THEN '%s'", bucket.max, bucket.label)
+		} else {
+			fmt.Fprintf(&cases, " ELSE '%s'", bucket.label)
+		}
+	}
+	cases.WriteString(" END")
+
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	err := filteredProducts(filters).
+		Select(cases.String() + " AS value, COUNT(*) AS count").
+		Group("value").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Value] = row.Count
+	}
+
+	// Report every bucket, in price order, including empty ones
+	buckets := make([]FacetBucket, 0, len(priceBuckets))
+	for _, bucket := range priceBuckets {
+		facet := FacetBucket{Value: bucket.label, Count: counts[bucket.label]}
+		lower := bucket.min
+		facet.Min = &lower
+		if bucket.max > 0 {
+			upper := bucket.max
+			facet.Max = &upper
+		}
+		buckets = append(buckets, facet)
+	}
+	return buckets, nil
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/geo"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// distanceExpr is the great-circle distance in km from a point to a product.
+// It takes the latitude, longitude and latitude again.
+const distanceExpr = "6371 * acos(LEAST(1.0, GREATEST(-1.0, " +
+	"cos(radians(?)) * cos(radians(products.latitude)) * cos(radians(products.longitude) - radians(?)) + " +
+	"sin(radians(?)) * sin(radians(products.latitude)))))"
+
+// distanceColumn rounds to 100 m. Listing coordinates are pincode centroids
+// to begin with, so this never reveals more than the rounded area.
+const distanceColumn = "ROUND((" + distanceExpr + ")::numeric, 1)::float8 AS distance_km"
+
+func parseLocationFilters(c *gin.Context, filters *models.ProductFilters) error {
+	latParam, lngParam := c.Query("lat"), c.Query("lng")
+	if latParam == "" && lngParam == "" {
+		if c.Query("radius_km") != "" {
+			return errors.New("radius_km requires lat and lng")
+		}
+		return nil
+	}
+
+	lat, err := strconv.ParseFloat(latParam, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return errors.New("lat must be a number between -90 and 90")
+	}
+	lng, err := strconv.ParseFloat(lngParam, 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return errors.New("lng must be a number between -180 and 180")
+	}
+	filters.Lat, filters.Lng = &lat, &lng
+
+	if radiusParam := c.Query("radius_km"); radiusParam != "" {
+		radius, err := strconv.ParseFloat(radiusParam, 64)
+		if err != nil || radius <= 0 || radius > models.MaxSearchRadiusKm {
+			return errors.New("radius_km must be greater than 0 and at most " + strconv.Itoa(models.MaxSearchRadiusKm))
+		}
+		filters.RadiusKm = radius
+	}
+	return nil
+}
+
+// withinRadius limits the query to products within radiusKm of the point.
+// The bounding box lets the coordinate index narrow the rows before the
+// exact distance is checked.
+func withinRadius(query *gorm.DB, lat, lng, radiusKm float64) *gorm.DB {
+	minLat, maxLat, minLng, maxLng := geo.BoundingBox(geo.Point{Lat: lat, Lng: lng}, radiusKm)
+	return query.
+		Where("products.latitude BETWEEN ? AND ?", minLat, maxLat).
+		Where("products.longitude BETWEEN ? AND ?", minLng, maxLng).
+		Where(distanceExpr+" <= ?", lat, lng, lat, radiusKm)
+}
+
+// productCoordinates resolves a pincode to the coordinates stored on a
+// listing. Unknown pincodes leave the listing without coordinates.
+func productCoordinates(pinCode string) (*float64, *float64) {
+	point, ok := geo.LookupPinCode(pinCode)
+	if !ok {
+		return nil, nil
+	}
+	return &point.Lat, &point.Lng
+}
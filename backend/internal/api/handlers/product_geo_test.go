@@ -0,0 +1,23 @@
+package handlers
+
+import "testing"
+
+func TestProductCoordinates(t *testing.T) {
+	tests := []struct {
+		pinCode  string
+		wantSome bool
+	}{
+		{"110001", true},
+		{"", false},
+		{"abc", false},
+	}
+	for _, tt := range tests {
+		lat, lng := productCoordinates(tt.pinCode)
+		if got := lat != nil && lng != nil; got != tt.wantSome {
+			t.Errorf("productCoordinates(%q) located = %v, want %v", tt.pinCode, got, tt.wantSome)
+		}
+		if (lat == nil) != (lng == nil) {
+			t.Errorf("productCoordinates(%q) = %v, %v, want both or neither", tt.pinCode, lat, lng)
+		}
+	}
+}
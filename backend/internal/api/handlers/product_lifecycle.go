@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/lifecycle"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// actionLabels describe the lifecycle actions in error messages.
+var actionLabels = map[lifecycle.Action]string{
+	lifecycle.ActionPublish:  "published",
+	lifecycle.ActionReserve:  "reserved",
+	lifecycle.ActionMarkSold: "marked as sold",
+	lifecycle.ActionRelist:   "relisted",
+	lifecycle.ActionHide:     "hidden",
+	lifecycle.ActionRenew:    "renewed",
+	lifecycle.ActionSubmit:   "submitted for review",
+	lifecycle.ActionApprove:  "approved",
+	lifecycle.ActionTakeDown: "taken down",
+	lifecycle.ActionRemove:   "removed",
+}
+
+type ProductStatusRequest struct {
+	// BuyerID optionally names who the product is reserved for or sold to
+	BuyerID *uint `json:"buyer_id"`
+	// Price is the final sale price when marking sold; it defaults to the
+	// accepted offer or the asking price
+	Price *float64 `json:"price" binding:"omitempty,gte=0"`
+}
+
+// Publish lists a draft product, or submits it for review when the seller's
+// listings need a moderator's approval.
+func (h *ProductHandler) Publish(c *gin.Context) {
+	action := lifecycle.ActionPublish
+	if userID, exists := c.Get("user_id"); exists {
+		review, err := requiresReview(userID.(uint))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if review {
+			action = lifecycle.ActionSubmit
+		}
+	}
+	h.changeStatus(c, action)
+}
+
+// Reserve holds an available product, optionally for a specific buyer.
+func (h *ProductHandler) Reserve(c *gin.Context) {
+	h.changeStatus(c, lifecycle.ActionReserve)
+}
+
+// MarkSold marks an available or reserved product as sold.
+func (h *ProductHandler) MarkSold(c *gin.Context) {
+	h.changeStatus(c, lifecycle.ActionMarkSold)
+}
+
+// Relist puts a reserved or hidden product back on the market.
+func (h *ProductHandler) Relist(c *gin.Context) {
+	h.changeStatus(c, lifecycle.ActionRelist)
+}
+
+// Hide takes a product off the market without deleting it.
+func (h *ProductHandler) Hide(c *gin.Context) {
+	h.changeStatus(c, lifecycle.ActionHide)
+}
+
+// Renew starts a new listing period for an available product, or puts an
+// expired one back on the market, up to LISTING_MAX_RENEWALS times.
+func (h *ProductHandler) Renew(c *gin.Context) {
+	h.changeStatus(c, lifecycle.ActionRenew)
+}
+
+func (h *ProductHandler) changeStatus(c *gin.Context, action lifecycle.Action) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	// The body is optional
+	var req ProductStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Find product and verify ownership
+	var product models.Product
+	result := repository.DB.First(&product, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	userIDUint := userID.(uint)
+	if product.UserID != userIDUint {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own products"})
+		return
+	}
+
+	if req.BuyerID != nil {
+		if action != lifecycle.ActionReserve && action != lifecycle.ActionMarkSold {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "buyer_id only applies when reserving or selling"})
+			return
+		}
+		if !validBuyer(*req.BuyerID, userIDUint) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid buyer ID"})
+			return
+		}
+	}
+
+	if req.Price != nil && action != lifecycle.ActionMarkSold {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price only applies when selling"})
+		return
+	}
+
+	// Selling to a known buyer records the sale
+	var sale *models.Transaction
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lifecycle.Apply(tx, &product, action, lifecycle.Options{BuyerID: req.BuyerID}); err != nil {
+			return err
+		}
+		if action == lifecycle.ActionMarkSold {
+			var err error
+			sale, err = recordSale(tx, &product, req.Price)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		respondLifecycleError(c, err, product.Status, action)
+		return
+	}
+
+	if sale != nil {
+		events.Emit(context.Background(), events.TransactionCreated, sale, sale.BuyerID)
+	}
+	if action == lifecycle.ActionMarkSold {
+		notifyFavoriters(events.FavoriteSold, &product, gin.H{})
+	}
+
+	// Load updated product with relationships
+	repository.DB.Preload("User").Preload("Category").First(&product, id)
+	attachSellers(&product)
+	markFavorited(c, &product)
+
+	response := gin.H{"product": product}
+	if sale != nil {
+		response["transaction"] = sale
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// validBuyer reports whether buyerID is an active user other than the seller.
+func validBuyer(buyerID, sellerID uint) bool {
+	if buyerID == sellerID {
+		return false
+	}
+	var count int64
+	err := repository.DB.Model(&models.User{}).
+		Where("id = ? AND is_active = ?", buyerID, true).
+		Count(&count).Error
+	return err == nil && count > 0
+}
+
+func respondLifecycleError(c *gin.Context, err error, status models.ProductStatus, action lifecycle.Action) {
+	switch err {
+	case lifecycle.ErrInvalidTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": "A product that is " + string(status) + " cannot be " + actionLabels[action]})
+	case lifecycle.ErrConflict:
+		c.JSON(http.StatusConflict, gin.H{"error": "The product was changed by another request; please retry"})
+	case lifecycle.ErrModerated:
+		c.JSON(http.StatusConflict, gin.H{"error": "This product was taken down by a moderator and cannot be relisted"})
+	case lifecycle.ErrSellerHidden:
+		c.JSON(http.StatusConflict, gin.H{"error": "This product was hidden by its seller and cannot be approved"})
+	case lifecycle.ErrRenewalLimit:
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A product can only be renewed %d times", config.AppConfig.ListingMaxRenewals)})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product status"})
+	}
+}
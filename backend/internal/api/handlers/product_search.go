@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseProductFilters(c *gin.Context) (models.ProductFilters, error) {
+	minPrice, _ := strconv.ParseFloat(c.Query("min_price"), 64)
+	maxPrice, _ := strconv.ParseFloat(c.Query("max_price"), 64)
+
+	filters := models.ProductFilters{
+		Search:    strings.TrimSpace(c.Query("search")),
+		Category:  c.Query("category"),
+		Condition: c.Query("condition"),
+		MinPrice:  minPrice,
+		MaxPrice:  maxPrice,
+	}
+
+	if err := parseLocationFilters(c, &filters); err != nil {
+		return filters, err
+	}
+	return filters, nil
+}
+
+// filteredProducts starts a new query over available products matching the
+// filters. Each call returns an independent query, so it can be reused for
+// counts and facets.
+func filteredProducts(filters models.ProductFilters) *gorm.DB {
+	query := repository.DB.Model(&models.Product{}).
+		Where("products.status = ?", models.ProductStatusAvailable)
+
+	if filters.Search != "" {
+		query = query.Where("products.search_vector @@ "+searchQuery, filters.Search)
+	}
+
+	if filters.Category != "" {
+		query = query.Where("products.category_id IN (SELECT id FROM categories WHERE name = ?)", filters.Category)
+	}
+
+	if filters.Condition != "" {
+		query = query.Where("products.condition = ?", filters.Condition)
+	}
+
+	if filters.MinPrice > 0 {
+		query = query.Where("products.price >= ?", filters.MinPrice)
+	}
+
+	if filters.MaxPrice > 0 {
+		query = query.Where("products.price <= ?", filters.MaxPrice)
+	}
+
+	if filters.HasLocation() && filters.RadiusKm > 0 {
+		query = withinRadius(query, *filters.Lat, *filters.Lng, filters.RadiusKm)
+	}
+
+	return query
+}
+
+// productColumns returns the select list for GetProducts, adding search and
+// distance columns when those filters are active. It returns an empty string
+// when the default columns suffice.
+func productColumns(filters models.ProductFilters) (string, []interface{}) {
+	columns := []string{"products.*"}
+	var args []interface{}
+
+	if filters.Search != "" {
+		columns = append(columns, searchColumns)
+		args = append(args, filters.Search, filters.Search, filters.Search)
+	}
+
+	if filters.HasLocation() {
+		columns = append(columns, distanceColumn)
+		args = append(args, *filters.Lat, *filters.Lng, *filters.Lat)
+	}
+
+	if len(columns) == 1 {
+		return "", nil
+	}
+	return strings.Join(columns, ", "), args
+}
+
+// Sentinels wrap matched terms in ts_headline output. They are swapped for
+// <mark> tags only after the listing text has been HTML-escaped.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+const searchQuery = "websearch_to_tsquery('english', ?)"
+
+// searchColumns are the relevance rank and highlighted snippets. They take
+// the search term three times.
+const searchColumns = "ts_rank_cd(products.search_vector, " + searchQuery + ") AS search_rank, " +
+	"ts_headline('english', products.title, " + searchQuery + ", " +
+	"'StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true') AS title_highlight, " +
+	"ts_headline('english', products.description, " + searchQuery + ", " +
+	"'StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxWords=35, MinWords=15, MaxFragments=2') AS description_snippet"
+
+// productSortColumns whitelists the values accepted by the sort parameter.
+var productSortColumns = map[string]string{
+	"created_at": "products.created_at",
+	"price":      "products.price",
+	"views":      "products.views",
+	"title":      "products.title",
+}
+
+// productOrder builds the ORDER BY clause for GetProducts. Relevance is only
+// available when searching and is the default in that case; distance needs a
+// location.
+func productOrder(sortBy, order string, filters models.ProductFilters) string {
+	direction := "DESC"
+	if strings.EqualFold(order, "asc") {
+		direction = "ASC"
+	}
+
+	searching := filters.Search != ""
+	if sortBy == "" && searching {
+		sortBy = "relevance"
+	}
+	switch sortBy {
+	case "relevance":
+		if searching {
+			return "search_rank DESC, products.created_at DESC"
+		}
+		sortBy = "created_at"
+	case "distance":
+		if filters.HasLocation() {
+			return "distance_km ASC NULLS LAST, products.created_at DESC"
+		}
+		sortBy = "created_at"
+	}
+
+	column, ok := productSortColumns[sortBy]
+	if !ok {
+		column = productSortColumns["created_at"]
+	}
+	return column + " " + direction + ", products.id DESC"
+}
+
+// applyHighlights escapes the highlighted snippets for safe rendering as HTML.
+func applyHighlights(products []models.Product) {
+	replacer := strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+	for i := range products {
+		products[i].TitleHighlight = replacer.Replace(html.EscapeString(products[i].TitleHighlight))
+		products[i].DescriptionSnippet = replacer.Replace(html.EscapeString(products[i].DescriptionSnippet))
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordEvents collects the events of the given type emitted during the test.
+func recordEvents(t *testing.T, eventType string) func() []events.Event {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		recorded []events.Event
+		done     bool
+	)
+	events.Subscribe(func(ctx context.Context, event events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !done && event.Type == eventType {
+			recorded = append(recorded, event)
+		}
+	})
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done = true
+	})
+	return func() []events.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return recorded
+	}
+}
+
+// newTestContext builds a request context for a handler, signed in as userID
+// unless it is 0. body is sent as JSON when not empty.
+func newTestContext(method, path, body string, userID uint, params ...gin.Param) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		c.Request.Header.Set("Content-Type", "application/json")
+	}
+	c.Params = params
+	if userID != 0 {
+		c.Set("user_id", userID)
+	}
+	return c, w
+}
+
+func TestUpdateProductNotifiesFavoritersOfPriceChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantPrice float64
+		wantEvent bool
+	}{
+		{"price drop", `{"price": 800}`, 800, true},
+		{"price rise", `{"price": 1200}`, 1200, true},
+		{"same price", `{"price": 1000}`, 1000, false},
+		{"price not sent", `{"title": "Oak desk"}`, 1000, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("products", map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "title": "Desk", "price": 1000.0, "status": "available",
+			})
+			db.insert("favorites", map[string]driver.Value{"id": int64(1), "user_id": int64(2), "product_id": int64(7)})
+			priceChanges := recordEvents(t, events.FavoritePriceChanged)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/products/7", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: "7"}}
+			c.Set("user_id", uint(1))
+
+			NewProductHandler().UpdateProduct(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			if price := db.rows("products")[0]["price"]; price != tt.wantPrice {
+				t.Errorf("stored price = %v, want %v", price, tt.wantPrice)
+			}
+
+			emitted := priceChanges()
+			if !tt.wantEvent {
+				if len(emitted) != 0 {
+					t.Errorf("emitted %d price change events, want none", len(emitted))
+				}
+				return
+			}
+			if len(emitted) != 1 {
+				t.Fatalf("emitted %d price change events, want 1", len(emitted))
+			}
+			data := emitted[0].Data.(gin.H)
+			if data["oldPrice"] != 1000.0 || data["newPrice"] != tt.wantPrice {
+				t.Errorf("event data = %v, want oldPrice 1000 and newPrice %v", data, tt.wantPrice)
+			}
+			if len(emitted[0].UserIDs) != 1 || emitted[0].UserIDs[0] != 2 {
+				t.Errorf("event addressed to %v, want [2]", emitted[0].UserIDs)
+			}
+		})
+	}
+}
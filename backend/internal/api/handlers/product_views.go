@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/jobs"
+)
+
+// productViews buffers view counts in memory. They are written in batches by
+// a job rather than with an UPDATE per request.
+var productViews = &viewCounter{counts: make(map[uint]int)}
+
+type viewCounter struct {
+	mu     sync.Mutex
+	counts map[uint]int
+}
+
+func (v *viewCounter) add(productID uint) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.counts[productID]++
+}
+
+// take returns the buffered counts and starts a new buffer.
+func (v *viewCounter) take() map[uint]int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	counts := v.counts
+	v.counts = make(map[uint]int)
+	return counts
+}
+
+// restore puts counts back after a failed flush.
+func (v *viewCounter) restore(counts map[uint]int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for id, n := range counts {
+		v.counts[id] += n
+	}
+}
+
+// FlushViews enqueues the buffered view counts.
+func FlushViews() error {
+	counts := productViews.take()
+	if len(counts) == 0 {
+		return nil
+	}
+	if err := jobs.Enqueue(repository.DB, jobs.KindRecordViews, counts, jobs.Options{}); err != nil {
+		productViews.restore(counts)
+		return err
+	}
+	return nil
+}
+
+// RunViewFlusher flushes the view counts every interval until ctx is
+// cancelled, and once more on the way out.
+func RunViewFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := FlushViews(); err != nil {
+				log.Printf("Failed to flush product views: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := FlushViews(); err != nil {
+				log.Printf("Failed to flush product views: %v", err)
+			}
+		}
+	}
+}
+
+// RecordViews is the job that adds a batch of view counts to the products.
+func RecordViews(ctx context.Context, job *models.Job) error {
+	return repository.DB.WithContext(ctx).Exec(`UPDATE products SET views = products.views + counts.value::int
+		FROM jsonb_each_text(?::jsonb) AS counts
+		WHERE products.id = counts.key::int`, job.Payload).Error
+}
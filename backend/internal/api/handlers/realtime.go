@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/api/middleware"
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/realtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// realtimeTicketTTL is how long a WebSocket ticket can be redeemed.
+const realtimeTicketTTL = 30 * time.Second
+
+type RealtimeHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+func NewRealtimeHandler(hub *realtime.Hub) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin,
+		},
+	}
+}
+
+// checkOrigin only accepts browser connections from the frontend. Requests
+// without an Origin header come from non-browser clients.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || strings.EqualFold(origin, config.AppConfig.FrontendURL)
+}
+
+// IssueTicket returns a single-use ticket for opening the WebSocket from a
+// browser, which cannot set the Authorization header on it. The ticket is
+// valid for realtimeTicketTTL, so it is useless by the time it shows up in
+// an access log.
+func (h *RealtimeHandler) IssueTicket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	sessionID, _ := c.Get("session_id")
+
+	ticket, ticketHash, err := generateOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue ticket"})
+		return
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		// Drop the user's tickets that were never used
+		if err := tx.Where("user_id = ? AND expires_at <= ?", userID, time.Now()).Delete(&models.RealtimeTicket{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.RealtimeTicket{
+			UserID:    userID.(uint),
+			SessionID: sessionID.(uint),
+			TokenHash: ticketHash,
+			ExpiresAt: time.Now().Add(realtimeTicketTTL),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expiresIn": int(realtimeTicketTTL.Seconds())})
+}
+
+// Connect upgrades the request to a WebSocket that receives the user's events.
+// Clients authenticate with the Authorization header or, from browsers, with
+// a ticket from IssueTicket in the ticket query parameter.
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	if h.hub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Realtime updates are unavailable"})
+		return
+	}
+
+	var userID uint
+	var expiresAt time.Time
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		claims, err := middleware.ValidateToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		userID, expiresAt = claims.UserID, claims.ExpiresAt.Time
+	} else if ticket := c.Query("ticket"); ticket != "" {
+		var ok bool
+		userID, expiresAt, ok = redeemRealtimeTicket(ticket)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			return
+		}
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token required"})
+		return
+	}
+
+	// Upgrade writes its own error response on failure
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	client := realtime.NewClient(h.hub, conn, userID, expiresAt)
+	client.Serve()
+}
+
+// redeemRealtimeTicket consumes a ticket and returns its user and when the
+// connection must end: one access token lifetime from now, as if the
+// client had connected with a fresh access token.
+func redeemRealtimeTicket(ticket string) (uint, time.Time, bool) {
+	var redeemed models.RealtimeTicket
+	result := repository.DB.Clauses(clause.Returning{}).
+		Where("token_hash = ? AND expires_at > ?", hashToken(ticket), time.Now()).
+		Delete(&redeemed)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return 0, time.Time{}, false
+	}
+
+	// The session may have been revoked since the ticket was issued
+	if !middleware.SessionActive(redeemed.UserID, redeemed.SessionID) {
+		return 0, time.Time{}, false
+	}
+
+	return redeemed.UserID, time.Now().Add(config.AppConfig.AccessTokenTTL), true
+}
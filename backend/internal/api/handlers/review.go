@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReviewHandler struct{}
+
+func NewReviewHandler() *ReviewHandler {
+	return &ReviewHandler{}
+}
+
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Body   string `json:"body" binding:"required,max=2000"`
+}
+
+type ReplyReviewRequest struct {
+	Reply string `json:"reply" binding:"required,max=2000"`
+}
+
+// CreateReview lets either party of a completed transaction review the other,
+// once.
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Review cannot be empty"})
+		return
+	}
+
+	transaction, ok := loadTransaction(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if transaction.Status != models.TransactionStatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reviews can be left once the buyer has confirmed receipt"})
+		return
+	}
+
+	review := models.Review{
+		TransactionID: transaction.ID,
+		ReviewerID:    userID.(uint),
+		Rating:        req.Rating,
+		Body:          body,
+	}
+	if transaction.BuyerID == review.ReviewerID {
+		review.RevieweeID = transaction.SellerID
+		review.ReviewerRole = "buyer"
+	} else {
+		review.RevieweeID = transaction.BuyerID
+		review.ReviewerRole = "seller"
+	}
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialize rating updates for the reviewee
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", review.RevieweeID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return refreshRating(tx, review.RevieweeID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reviewed this transaction"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"review": review})
+}
+
+// ReplyToReview lets the reviewed user answer a review, once.
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req ReplyReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reply := strings.TrimSpace(req.Reply)
+	if reply == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reply cannot be empty"})
+		return
+	}
+
+	var review models.Review
+	result := repository.DB.First(&review, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if review.RevieweeID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the reviewed user can reply"})
+		return
+	}
+
+	now := time.Now()
+	result = repository.DB.Model(&models.Review{}).
+		Where("id = ? AND replied_at IS NULL", review.ID).
+		Updates(map[string]interface{}{"reply": reply, "replied_at": now})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reply"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already replied to this review"})
+		return
+	}
+
+	review.Reply = reply
+	review.RepliedAt = &now
+
+	c.JSON(http.StatusOK, gin.H{"review": review})
+}
+
+// GetUserReviews lists the reviews a user has received, newest first.
+func (h *ReviewHandler) GetUserReviews(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.User
+	result := repository.DB.Select("id", "rating", "review_count").First(&user, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 20)
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Review{}).Where("reviews.reviewee_id = ?", user.ID)
+
+	if role := c.Query("role"); role != "" {
+		query = query.Where("reviews.reviewer_role = ?", role)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var reviews []models.Review
+	result = query.
+		Select("reviews.*, transactions.product_id, products.title AS product_title").
+		Joins("JOIN transactions ON transactions.id = reviews.transaction_id").
+		Joins("JOIN products ON products.id = transactions.product_id").
+		Preload("Reviewer", participantColumns).
+		Order("reviews.created_at DESC, reviews.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&reviews)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews":     reviews,
+		"rating":      user.Rating,
+		"reviewCount": user.ReviewCount,
+		"pagination":  paginationInfo(page, limit, total),
+	})
+}
+
+// refreshRating recomputes a user's aggregate rating from their reviews.
+func refreshRating(db *gorm.DB, userID uint) error {
+	return db.Exec(`UPDATE users SET
+		rating = COALESCE((SELECT ROUND(AVG(rating), 2) FROM reviews WHERE reviewee_id = ?), 0),
+		review_count = (SELECT COUNT(*) FROM reviews WHERE reviewee_id = ?)
+		WHERE id = ?`, userID, userID, userID).Error
+}
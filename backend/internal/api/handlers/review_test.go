@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateReview(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		userID       uint
+		body         string
+		wantCode     int
+		wantReviewee int64
+		wantRole     string
+	}{
+		{"buyer reviews the seller", "completed", 2, `{"rating": 5, "body": "Smooth sale"}`, http.StatusCreated, 1, "buyer"},
+		{"seller reviews the buyer", "completed", 1, `{"rating": 4, "body": " Paid on time "}`, http.StatusCreated, 2, "seller"},
+		{"before receipt is confirmed", "pending", 2, `{"rating": 5, "body": "Smooth sale"}`, http.StatusConflict, 0, ""},
+		{"stranger", "completed", 3, `{"rating": 5, "body": "Smooth sale"}`, http.StatusNotFound, 0, ""},
+		{"blank body", "completed", 2, `{"rating": 5, "body": "   "}`, http.StatusBadRequest, 0, ""},
+		{"rating out of range", "completed", 2, `{"rating": 6, "body": "Smooth sale"}`, http.StatusBadRequest, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("transactions", map[string]driver.Value{
+				"id": int64(4), "product_id": int64(7), "seller_id": int64(1), "buyer_id": int64(2),
+				"price": 1000.0, "status": tt.status, "sold_at": time.Now(),
+			})
+
+			c, w := newTestContext(http.MethodPost, "/transactions/4/reviews", tt.body, tt.userID, gin.Param{Key: "id", Value: "4"})
+			NewReviewHandler().CreateReview(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			reviews := db.rows("reviews")
+			refreshes := db.executed("UPDATE users SET")
+			if tt.wantCode != http.StatusCreated {
+				if len(reviews) != 0 || len(refreshes) != 0 {
+					t.Errorf("stored %v and refreshed %d ratings, want neither", reviews, len(refreshes))
+				}
+				return
+			}
+
+			if len(reviews) != 1 {
+				t.Fatalf("stored %d reviews, want 1", len(reviews))
+			}
+			review := reviews[0]
+			if review["reviewee_id"] != tt.wantReviewee || review["reviewer_role"] != tt.wantRole {
+				t.Errorf("review of %v as %v, want of %d as %s", review["reviewee_id"], review["reviewer_role"], tt.wantReviewee, tt.wantRole)
+			}
+			if body := review["body"].(string); body != strings.TrimSpace(body) {
+				t.Errorf("body %q was not trimmed", body)
+			}
+
+			if len(refreshes) != 1 {
+				t.Fatalf("refreshed %d ratings, want 1", len(refreshes))
+			}
+			for _, arg := range refreshes[0].args {
+				if arg != tt.wantReviewee {
+					t.Errorf("refreshed the rating with %v, want user %d", refreshes[0].args, tt.wantReviewee)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestReplyToReview(t *testing.T) {
+	tests := []struct {
+		name      string
+		replied   bool
+		userID    uint
+		body      string
+		wantCode  int
+		wantReply string
+	}{
+		{"reviewee replies", false, 1, `{"reply": "Thanks!"}`, http.StatusOK, "Thanks!"},
+		{"reviewer replies", false, 2, `{"reply": "Thanks!"}`, http.StatusForbidden, ""},
+		{"second reply", true, 1, `{"reply": "Thanks again"}`, http.StatusConflict, "Thanks!"},
+		{"blank reply", false, 1, `{"reply": " "}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			review := map[string]driver.Value{
+				"id": int64(3), "transaction_id": int64(4), "reviewer_id": int64(2), "reviewee_id": int64(1),
+				"rating": int64(5), "body": "Smooth sale", "reply": "",
+			}
+			if tt.replied {
+				review["reply"], review["replied_at"] = "Thanks!", time.Now()
+			}
+			db.insert("reviews", review)
+
+			c, w := newTestContext(http.MethodPost, "/reviews/3/reply", tt.body, tt.userID, gin.Param{Key: "id", Value: "3"})
+			NewReviewHandler().ReplyToReview(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			if reply := db.rows("reviews")[0]["reply"]; reply != tt.wantReply {
+				t.Errorf("reply = %v, want %q", reply, tt.wantReply)
+			}
+		})
+	}
+}
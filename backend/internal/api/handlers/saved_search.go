@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxMatchesPerRun caps how many new listings one saved search takes in
+	// per matcher run. The rest are picked up by the next run.
+	maxMatchesPerRun = 100
+	// digestInterval is the minimum time between daily digests
+	digestInterval = 24 * time.Hour
+	// matchOverlap is how far behind the database's clock the matcher leaves
+	// its watermark. listed_at is set by the app before the publishing
+	// transaction commits, so a listing can become visible after a run has
+	// passed its listed_at; the next run rescans the overlap to find it, and
+	// the unique match row keeps it from being alerted twice.
+	matchOverlap = 5 * time.Minute
+)
+
+var errSavedSearchLimit = errors.New("saved search limit reached")
+
+type SavedSearchHandler struct{}
+
+func NewSavedSearchHandler() *SavedSearchHandler {
+	return &SavedSearchHandler{}
+}
+
+type SavedSearchRequest struct {
+	Name      string                `json:"name" binding:"required,max=100"`
+	Filters   models.ProductFilters `json:"filters"`
+	AlertMode models.AlertMode      `json:"alert_mode"`
+}
+
+// validate normalizes the request and checks it. The error is safe to show
+// to the client.
+func (r *SavedSearchRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("Name cannot be empty")
+	}
+
+	r.Filters.Search = strings.TrimSpace(r.Filters.Search)
+	if r.Filters.IsEmpty() {
+		return errors.New("Add at least one filter to save a search")
+	}
+	if err := r.Filters.Validate(); err != nil {
+		return err
+	}
+
+	if r.AlertMode == "" {
+		r.AlertMode = models.AlertModeInstant
+	}
+	if !r.AlertMode.IsValid() {
+		return errors.New("alert_mode must be instant or daily")
+	}
+	return nil
+}
+
+// CreateSavedSearch saves a product search. Only listings published after
+// this point are matched.
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search := models.SavedSearch{
+		UserID:        userID.(uint),
+		Name:          req.Name,
+		Filters:       req.Filters,
+		AlertMode:     req.AlertMode,
+		LastMatchedAt: time.Now(),
+	}
+
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialize creation per user so concurrent requests cannot exceed the limit
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", search.UserID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.SavedSearch{}).Where("user_id = ?", search.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(config.AppConfig.SavedSearchLimit) {
+			return errSavedSearchLimit
+		}
+
+		return tx.Create(&search).Error
+	})
+	if err != nil {
+		if errors.Is(err, errSavedSearchLimit) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can save up to %d searches. Delete one to save another", config.AppConfig.SavedSearchLimit)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save search"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"savedSearch": search})
+}
+
+// GetSavedSearches lists the user's saved searches, newest first.
+func (h *SavedSearchHandler) GetSavedSearches(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var searches []models.SavedSearch
+	result := repository.DB.Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&searches)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"savedSearches": searches,
+		"limit":         config.AppConfig.SavedSearchLimit,
+	})
+}
+
+// UpdateSavedSearch replaces a saved search's name, filters and alert mode.
+// Listings already matched are kept.
+func (h *SavedSearchHandler) UpdateSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	search, ok := loadSavedSearch(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search.Name = req.Name
+	search.Filters = req.Filters
+	search.AlertMode = req.AlertMode
+
+	// Struct updates go through the JSON serializer for filters
+	result := repository.DB.Model(search).Select("name", "filters", "alert_mode").Updates(search)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"savedSearch": search})
+}
+
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	search, ok := loadSavedSearch(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if err := repository.DB.Delete(search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted"})
+}
+
+// GetSavedSearchMatches lists the available listings matched by a saved
+// search, most recently matched first.
+func (h *SavedSearchHandler) GetSavedSearchMatches(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	search, ok := loadSavedSearch(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 12)
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Product{}).
+		Joins("JOIN saved_search_matches ON saved_search_matches.product_id = products.id").
+		Where("saved_search_matches.saved_search_id = ?", search.ID).
+		Where("products.status = ?", models.ProductStatusAvailable)
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var products []models.Product
+	result := query.
+		Preload("User").
+		Preload("Category").
+		Order("saved_search_matches.created_at DESC, saved_search_matches.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&products)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch matches"})
+		return
+	}
+
+	productRefs := make([]*models.Product, len(products))
+	for i := range products {
+		productRefs[i] = &products[i]
+	}
+	if err := attachSellers(productRefs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sellers"})
+		return
+	}
+	if err := markFavorited(c, productRefs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch favorites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"savedSearch": search,
+		"products":    products,
+		"pagination":  paginationInfo(page, limit, total),
+	})
+}
+
+// loadSavedSearch loads the user's saved search named by the :id parameter.
+func loadSavedSearch(c *gin.Context, userID uint) (*models.SavedSearch, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID"})
+		return nil, false
+	}
+
+	var search models.SavedSearch
+	result := repository.DB.Where("id = ? AND user_id = ?", id, userID).First(&search)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	return &search, true
+}
+
+// MatchSavedSearches is the job that runs every saved search against the
+// listings published since its last run and notifies the owners. A failing
+// search is logged and does not stop the others.
+func MatchSavedSearches(ctx context.Context, job *models.Job) error {
+	var searches []models.SavedSearch
+	return repository.DB.WithContext(ctx).Order("id").
+		FindInBatches(&searches, 100, func(tx *gorm.DB, batch int) error {
+			for i := range searches {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := matchSavedSearch(ctx, &searches[i]); err != nil {
+					log.Printf("Failed to match saved search %d: %v", searches[i].ID, err)
+				}
+			}
+			return nil
+		}).Error
+}
+
+// matchSavedSearch records the listings published since the search last ran and sends
+// the alerts that are due.
+func matchSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	// Use the database's clock, which every run shares
+	var runAt time.Time
+	if err := repository.DB.WithContext(ctx).Raw("SELECT now()").Scan(&runAt).Error; err != nil {
+		return err
+	}
+
+	// Listings are taken in (listed_at, id) order after the watermark, so
+	// listings sharing a timestamp are not skipped across capped runs
+	var listings []matchedListing
+	err := filteredProducts(search.Filters).WithContext(ctx).
+		Select("products.id, products.listed_at").
+		Where("(products.listed_at, products.id) > (?, ?)", search.LastMatchedAt, search.LastMatchedID).
+		Where("products.user_id <> ?", search.UserID).
+		Order("products.listed_at, products.id").
+		Limit(maxMatchesPerRun).
+		Scan(&listings).Error
+	if err != nil {
+		return err
+	}
+	matchedAt, matchedID := nextWatermark(search, listings, runAt)
+
+	// A listing that is re-listed keeps its earlier match, so only new rows
+	// are alerted about
+	var newIDs []uint
+	for _, listing := range listings {
+		match := models.SavedSearchMatch{SavedSearchID: search.ID, ProductID: listing.ID}
+		result := repository.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&match)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			newIDs = append(newIDs, listing.ID)
+		}
+	}
+
+	err = repository.DB.WithContext(ctx).Model(&models.SavedSearch{}).
+		Where("id = ?", search.ID).
+		UpdateColumns(map[string]interface{}{"last_matched_at": matchedAt, "last_matched_id": matchedID}).Error
+	if err != nil {
+		return err
+	}
+
+	if search.AlertMode == models.AlertModeDaily {
+		return sendSavedSearchDigest(ctx, search, runAt)
+	}
+	return alertSavedSearch(ctx, search, newIDs, runAt)
+}
+
+// matchedListing is a listing taken in by a matcher run.
+type matchedListing struct {
+	ID       uint
+	ListedAt time.Time
+}
+
+// nextWatermark returns where the next run of a search resumes. When the
+// run was capped it resumes after the last listing taken; otherwise the
+// watermark is left matchOverlap behind runAt. It never moves backwards.
+func nextWatermark(search *models.SavedSearch, listings []matchedListing, runAt time.Time) (time.Time, uint) {
+	matchedAt, matchedID := runAt.Add(-matchOverlap), uint(0)
+	if len(listings) == maxMatchesPerRun {
+		last := listings[len(listings)-1]
+		matchedAt, matchedID = last.ListedAt, last.ID
+	}
+	if matchedAt.Before(search.LastMatchedAt) || (matchedAt.Equal(search.LastMatchedAt) && matchedID < search.LastMatchedID) {
+		return search.LastMatchedAt, search.LastMatchedID
+	}
+	return matchedAt, matchedID
+}
+
+func alertSavedSearch(ctx context.Context, search *models.SavedSearch, productIDs []uint, now time.Time) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	err := repository.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.SavedSearchMatch{}).
+			Where("saved_search_id = ? AND product_id IN ?", search.ID, productIDs).
+			UpdateColumn("notified_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.SavedSearch{}).Where("id = ?", search.ID).
+			UpdateColumn("last_notified_at", now).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	events.Emit(ctx, events.SavedSearchMatched, gin.H{
+		"savedSearchId": search.ID,
+		"name":          search.Name,
+		"productIds":    productIDs,
+		"count":         len(productIDs),
+	}, search.UserID)
+	return nil
+}
+
+// sendSavedSearchDigest sends the matches not yet notified as a digest, at most once
+// per digestInterval. Matches whose listing has since left the market are
+// dropped from the digest.
+func sendSavedSearchDigest(ctx context.Context, search *models.SavedSearch, now time.Time) error {
+	if search.LastNotifiedAt != nil && now.Sub(*search.LastNotifiedAt) < digestInterval {
+		return nil
+	}
+
+	var products []models.Product
+	err := repository.DB.WithContext(ctx).
+		Joins("JOIN saved_search_matches ON saved_search_matches.product_id = products.id").
+		Where("saved_search_matches.saved_search_id = ? AND saved_search_matches.notified_at IS NULL", search.ID).
+		Where("products.status = ?", models.ProductStatusAvailable).
+		Order("saved_search_matches.created_at DESC, saved_search_matches.id DESC").
+		Find(&products).Error
+	if err != nil {
+		return err
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	// Claim the digest so that concurrent matchers send it once
+	claimed := false
+	err = repository.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.SavedSearch{}).
+			Where("id = ? AND last_notified_at IS NOT DISTINCT FROM ?", search.ID, search.LastNotifiedAt).
+			UpdateColumn("last_notified_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+		return tx.Model(&models.SavedSearchMatch{}).
+			Where("saved_search_id = ? AND notified_at IS NULL", search.ID).
+			UpdateColumn("notified_at", now).Error
+	})
+	if err != nil || !claimed {
+		return err
+	}
+
+	productIDs := make([]uint, len(products))
+	listings := make([]gin.H, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+		listings[i] = gin.H{"id": product.ID, "title": product.Title, "price": product.Price}
+	}
+	events.Emit(ctx, events.SavedSearchDigest, gin.H{
+		"savedSearchId": search.ID,
+		"name":          search.Name,
+		"productIds":    productIDs,
+		"products":      listings,
+		"count":         len(productIDs),
+	}, search.UserID)
+	return nil
+}
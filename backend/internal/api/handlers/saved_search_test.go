@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/models"
+)
+
+func TestNextWatermark(t *testing.T) {
+	runAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	last := runAt.Add(-time.Hour)
+
+	// capped is a full run whose last listing is listed at the given time
+	capped := func(listedAt time.Time, id uint) []matchedListing {
+		listings := make([]matchedListing, maxMatchesPerRun)
+		listings[len(listings)-1] = matchedListing{ID: id, ListedAt: listedAt}
+		return listings
+	}
+
+	tests := []struct {
+		name     string
+		lastAt   time.Time
+		lastID   uint
+		listings []matchedListing
+		wantAt   time.Time
+		wantID   uint
+	}{
+		{"no listings", last, 0, nil, runAt.Add(-matchOverlap), 0},
+		{"some listings", last, 0, []matchedListing{{ID: 9, ListedAt: runAt}}, runAt.Add(-matchOverlap), 0},
+		{"capped run", last, 0, capped(last.Add(time.Minute), 42), last.Add(time.Minute), 42},
+		{"capped within one timestamp", last, 40, capped(last, 42), last, 42},
+		{"clock behind the watermark", runAt, 7, nil, runAt, 7},
+		{"overlap behind the watermark", runAt.Add(-time.Minute), 7, nil, runAt.Add(-time.Minute), 7},
+	}
+	for _, tt := range tests {
+		search := &models.SavedSearch{LastMatchedAt: tt.lastAt, LastMatchedID: tt.lastID}
+		gotAt, gotID := nextWatermark(search, tt.listings, runAt)
+		if !gotAt.Equal(tt.wantAt) || gotID != tt.wantID {
+			t.Errorf("%s: watermark = (%v, %d), want (%v, %d)", tt.name, gotAt, gotID, tt.wantAt, tt.wantID)
+		}
+	}
+}
+
+func TestSavedSearchRequestValidate(t *testing.T) {
+	lat := 19.07
+	tests := []struct {
+		name     string
+		req      SavedSearchRequest
+		wantErr  bool
+		wantMode models.AlertMode
+	}{
+		{"instant by default", SavedSearchRequest{Name: " Bikes ", Filters: models.ProductFilters{Search: "bike"}}, false, models.AlertModeInstant},
+		{"daily", SavedSearchRequest{Name: "Bikes", Filters: models.ProductFilters{Category: "vehicles"}, AlertMode: models.AlertModeDaily}, false, models.AlertModeDaily},
+		{"blank name", SavedSearchRequest{Name: "  ", Filters: models.ProductFilters{Search: "bike"}}, true, ""},
+		{"no filters", SavedSearchRequest{Name: "Everything"}, true, ""},
+		{"blank search only", SavedSearchRequest{Name: "Bikes", Filters: models.ProductFilters{Search: "   "}}, true, ""},
+		{"invalid filters", SavedSearchRequest{Name: "Bikes", Filters: models.ProductFilters{Lat: &lat}}, true, ""},
+		{"unknown alert mode", SavedSearchRequest{Name: "Bikes", Filters: models.ProductFilters{Search: "bike"}, AlertMode: "hourly"}, true, ""},
+	}
+	for _, tt := range tests {
+		err := tt.req.validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validate() = %v, want error: %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (tt.req.AlertMode != tt.wantMode || tt.req.Name != "Bikes") {
+			t.Errorf("%s: normalized to name %q and mode %q, want \"Bikes\" and %q", tt.name, tt.req.Name, tt.req.AlertMode, tt.wantMode)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"bech-do-backend/internal/api/middleware"
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+	All          bool   `json:"all"`
+}
+
+// generateOpaqueToken returns a random URL-safe token together with the
+// SHA-256 hash that is stored in the database.
+func generateOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens opens a new session for the user and returns the access and
+// refresh tokens for it.
+func issueTokens(c *gin.Context, user models.User) (*AuthResponse, error) {
+	refreshToken, refreshHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		UserAgent:        c.Request.UserAgent(),
+		IPAddress:        c.ClientIP(),
+		ExpiresAt:        time.Now().Add(config.AppConfig.RefreshTokenTTL),
+	}
+	if err := repository.DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	token, err := middleware.GenerateJWT(user.ID, user.Email, user.Role, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+
+	return &AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AppConfig.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+// revokeUserSessions revokes every active session of the user, which also
+// invalidates all access tokens issued for them.
+func revokeUserSessions(db *gorm.DB, userID uint) error {
+	return db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokenHash := hashToken(req.RefreshToken)
+
+	var session models.Session
+	result := repository.DB.Where("refresh_token_hash = ?", tokenHash).First(&session)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			// A rotated-out token being presented again means it has leaked,
+			// so the whole session is revoked
+			repository.DB.Model(&models.Session{}).
+				Where("previous_token_hash = ? AND revoked_at IS NULL", tokenHash).
+				Update("revoked_at", time.Now())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has expired or been revoked"})
+		return
+	}
+
+	var user models.User
+	result = repository.DB.Where("id = ? AND is_active = ?", session.UserID, true).First(&user)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has expired or been revoked"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Rotate the refresh token
+	newToken, newHash, err := generateOpaqueToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	now := time.Now()
+	result = repository.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, tokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  newHash,
+			"previous_token_hash": tokenHash,
+			"last_used_at":        now,
+			"expires_at":          now.Add(config.AppConfig.RefreshTokenTTL),
+			"user_agent":          c.Request.UserAgent(),
+			"ip_address":          c.ClientIP(),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Another request rotated this token first
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	token, err := middleware.GenerateJWT(user.ID, user.Email, user.Role, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	// Remove password from response
+	user.Password = ""
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Token:        token,
+		RefreshToken: newToken,
+		ExpiresIn:    int(config.AppConfig.AccessTokenTTL.Seconds()),
+		User:         user,
+	})
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var session models.Session
+	result := repository.DB.Where("refresh_token_hash = ?", hashToken(req.RefreshToken)).First(&session)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			// Logging out an unknown or already rotated token is a no-op
+			c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var err error
+	if req.All {
+		err = revokeUserSessions(repository.DB, session.UserID)
+	} else {
+		err = repository.DB.Model(&session).
+			Where("revoked_at IS NULL").
+			Update("revoked_at", time.Now()).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
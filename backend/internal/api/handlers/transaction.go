@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TransactionHandler struct{}
+
+func NewTransactionHandler() *TransactionHandler {
+	return &TransactionHandler{}
+}
+
+// GetPurchases lists the user's purchases.
+func (h *TransactionHandler) GetPurchases(c *gin.Context) {
+	h.listTransactions(c, "buyer_id")
+}
+
+// GetSales lists the user's sales.
+func (h *TransactionHandler) GetSales(c *gin.Context) {
+	h.listTransactions(c, "seller_id")
+}
+
+func (h *TransactionHandler) listTransactions(c *gin.Context, party string) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 20)
+	status := c.Query("status")
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Transaction{}).Where(party+" = ?", userID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var transactions []models.Transaction
+	result := transactionRelations(query).
+		Order("sold_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&transactions)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"pagination":   paginationInfo(page, limit, total),
+	})
+}
+
+func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	transaction, ok := loadTransaction(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
+}
+
+// ConfirmReceipt lets the buyer confirm they received the item, which
+// completes the transaction.
+func (h *TransactionHandler) ConfirmReceipt(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	transaction, ok := loadTransaction(c, userID.(uint))
+	if !ok {
+		return
+	}
+
+	if transaction.BuyerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the buyer can confirm receipt"})
+		return
+	}
+
+	now := time.Now()
+	result := repository.DB.Model(&models.Transaction{}).
+		Where("id = ? AND status = ?", transaction.ID, models.TransactionStatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.TransactionStatusCompleted,
+			"confirmed_at": now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm receipt"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This transaction is already completed"})
+		return
+	}
+
+	transaction.Status = models.TransactionStatusCompleted
+	transaction.ConfirmedAt = &now
+	realtime.Publish("transaction.completed", transaction, transaction.SellerID)
+
+	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
+}
+
+// transactionRelations preloads the product, including deleted ones so the
+// history stays intact, and the public fields of both parties.
+func transactionRelations(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Seller", participantColumns).
+		Preload("Buyer", participantColumns)
+}
+
+// loadTransaction loads the transaction named by the :id parameter. Users who
+// are not party to it get a 404.
+func loadTransaction(c *gin.Context, userID uint) (*models.Transaction, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return nil, false
+	}
+
+	var transaction models.Transaction
+	result := transactionRelations(repository.DB).
+		Where("id = ? AND (buyer_id = ? OR seller_id = ?)", id, userID, userID).
+		First(&transaction)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	return &transaction, true
+}
+
+// recordSale creates the transaction for a product that was just marked sold
+// to a buyer. The price is the one given, else the buyer's accepted offer,
+// else the asking price. Products sold without a known buyer get no
+// transaction.
+func recordSale(tx *gorm.DB, product *models.Product, price *float64) (*models.Transaction, error) {
+	if product.ReservedForID == nil || product.SoldAt == nil {
+		return nil, nil
+	}
+
+	transaction := models.Transaction{
+		ProductID: product.ID,
+		SellerID:  product.UserID,
+		BuyerID:   *product.ReservedForID,
+		Price:     product.Price,
+		Status:    models.TransactionStatusPending,
+		SoldAt:    *product.SoldAt,
+	}
+
+	var offer models.Offer
+	result := tx.Where("product_id = ? AND buyer_id = ? AND status = ?", product.ID, transaction.BuyerID, models.OfferStatusAccepted).
+		First(&offer)
+	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+		return nil, result.Error
+	}
+	if result.Error == nil {
+		transaction.OfferID = &offer.ID
+		if offer.AgreedAmount != nil {
+			transaction.Price = *offer.AgreedAmount
+		}
+	}
+
+	if price != nil {
+		transaction.Price = *price
+	}
+
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
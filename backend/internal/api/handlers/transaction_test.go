@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/services/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMarkSoldRecordsTheSale(t *testing.T) {
+	setOfferConfig(t)
+
+	tests := []struct {
+		name        string
+		status      string
+		reservedFor driver.Value
+		offer       driver.Value
+		body        string
+		wantCode    int
+		wantSale    bool
+		wantPrice   float64
+		wantOffer   driver.Value
+	}{
+		{"to a buyer at the asking price", "available", nil, nil, `{"buyer_id": 2}`, http.StatusOK, true, 1000, nil},
+		{"to a buyer at another price", "available", nil, nil, `{"buyer_id": 2, "price": 900}`, http.StatusOK, true, 900, nil},
+		{"to the reserved buyer at the agreed price", "reserved", int64(2), 850.0, "", http.StatusOK, true, 850, int64(1)},
+		{"price given over the agreed price", "reserved", int64(2), 850.0, `{"price": 800}`, http.StatusOK, true, 800, int64(1)},
+		{"without a buyer", "available", nil, nil, "", http.StatusOK, false, 0, nil},
+		{"to the seller", "available", nil, nil, `{"buyer_id": 1}`, http.StatusBadRequest, false, 0, nil},
+		{"to an unknown buyer", "available", nil, nil, `{"buyer_id": 9}`, http.StatusBadRequest, false, 0, nil},
+		{"already sold", "sold", nil, nil, `{"buyer_id": 2}`, http.StatusConflict, false, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("users", map[string]driver.Value{"id": int64(2), "is_active": true})
+			db.insert("products", map[string]driver.Value{
+				"id": int64(7), "user_id": int64(1), "category_id": int64(1), "price": 1000.0,
+				"status": tt.status, "reserved_for_id": tt.reservedFor,
+			})
+			if tt.offer != nil {
+				db.insert("offers", map[string]driver.Value{
+					"id": int64(1), "product_id": int64(7), "buyer_id": int64(2), "seller_id": int64(1),
+					"amount": 800.0, "agreed_amount": tt.offer, "status": "accepted",
+				})
+			}
+			sales := recordEvents(t, events.TransactionCreated)
+
+			c, w := newTestContext(http.MethodPost, "/products/7/mark-sold", tt.body, 1, gin.Param{Key: "id", Value: "7"})
+			NewProductHandler().MarkSold(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			transactions := db.rows("transactions")
+			if !tt.wantSale {
+				if len(transactions) != 0 || len(sales()) != 0 {
+					t.Errorf("recorded %v and emitted %d events, want no sale", transactions, len(sales()))
+				}
+				return
+			}
+			if len(transactions) != 1 {
+				t.Fatalf("recorded %d transactions, want 1", len(transactions))
+			}
+			sale := transactions[0]
+			if sale["buyer_id"] != int64(2) || sale["seller_id"] != int64(1) || sale["status"] != "pending" {
+				t.Errorf("transaction = %v, want a pending sale from 1 to 2", sale)
+			}
+			if sale["price"] != tt.wantPrice {
+				t.Errorf("price = %v, want %v", sale["price"], tt.wantPrice)
+			}
+			if sale["offer_id"] != tt.wantOffer {
+				t.Errorf("offer_id = %v, want %v", sale["offer_id"], tt.wantOffer)
+			}
+			if emitted := sales(); len(emitted) != 1 || emitted[0].UserIDs[0] != 2 {
+				t.Errorf("emitted %v, want one sale event for the buyer", emitted)
+			}
+		})
+	}
+}
+
+func TestConfirmReceipt(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		userID     uint
+		wantCode   int
+		wantStatus string
+	}{
+		{"buyer confirms", "pending", 2, http.StatusOK, "completed"},
+		{"seller confirms", "pending", 1, http.StatusForbidden, "pending"},
+		{"already completed", "completed", 2, http.StatusConflict, "completed"},
+		{"stranger", "pending", 3, http.StatusNotFound, "pending"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.insert("transactions", map[string]driver.Value{
+				"id": int64(4), "product_id": int64(7), "seller_id": int64(1), "buyer_id": int64(2),
+				"price": 1000.0, "status": tt.status, "sold_at": time.Now(),
+			})
+
+			c, w := newTestContext(http.MethodPost, "/transactions/4/confirm", "", tt.userID, gin.Param{Key: "id", Value: "4"})
+			NewTransactionHandler().ConfirmReceipt(c)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantCode, w.Body)
+			}
+			stored := db.rows("transactions")[0]
+			if stored["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", stored["status"], tt.wantStatus)
+			}
+			if confirmed := stored["confirmed_at"] != nil; confirmed != (tt.wantCode == http.StatusOK) {
+				t.Errorf("confirmed_at = %v after a %d", stored["confirmed_at"], w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/imaging"
+	"bech-do-backend/internal/services/jobs"
+	"bech-do-backend/internal/services/storage"
+
+	"github.com/gin-gonic/gin"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+const maxImagesPerUpload = 10
+
+// allowedImageTypes lists the accepted content types, as sniffed from the
+// file contents. Everything is re-encoded to JPEG before it is stored.
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+type UploadHandler struct {
+	storage storage.Storage
+}
+
+func NewUploadHandler(store storage.Storage) *UploadHandler {
+	return &UploadHandler{storage: store}
+}
+
+type imageValidationError struct {
+	message string
+}
+
+func (e *imageValidationError) Error() string {
+	return e.message
+}
+
+func (h *UploadHandler) UploadImages(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	maxSize := config.AppConfig.MaxUploadSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize*maxImagesPerUpload+(1<<20))
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form or upload too large"})
+		return
+	}
+
+	files := form.File["images"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one file is required in the \"images\" field"})
+		return
+	}
+	if len(files) > maxImagesPerUpload {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d images can be uploaded at once", maxImagesPerUpload)})
+		return
+	}
+
+	uploads := make([]models.Upload, 0, len(files))
+	for _, file := range files {
+		upload, err := h.storeImage(c, userID.(uint), file)
+		if err != nil {
+			var validationErr *imageValidationError
+			if errors.As(err, &validationErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": file.Filename + ": " + validationErr.message})
+				return
+			}
+			log.Printf("Failed to store upload for user %d: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store image"})
+			return
+		}
+		uploads = append(uploads, *upload)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"images": uploads})
+}
+
+func (h *UploadHandler) storeImage(c *gin.Context, userID uint, file *multipart.FileHeader) (*models.Upload, error) {
+	maxSize := config.AppConfig.MaxUploadSize
+	if file.Size > maxSize {
+		return nil, &imageValidationError{fmt.Sprintf("file exceeds the %d MB limit", maxSize>>20)}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, &imageValidationError{fmt.Sprintf("file exceeds the %d MB limit", maxSize>>20)}
+	}
+
+	// Trust the file contents, not the client-supplied content type
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		return nil, &imageValidationError{"only JPEG, PNG and WebP images are allowed"}
+	}
+
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, &imageValidationError{"file is not a valid image"}
+	}
+	if err := validateDimensions(imgConfig.Width, imgConfig.Height); err != nil {
+		return nil, err
+	}
+
+	// Strip metadata and fix orientation before anything is stored. The
+	// resized variants are generated by a job.
+	original, err := imaging.Normalize(data)
+	if err != nil {
+		return nil, &imageValidationError{"image could not be processed"}
+	}
+
+	key := fmt.Sprintf("images/%d/%s.jpg", userID, randomName())
+	url, err := h.storage.Save(c.Request.Context(), key, bytes.NewReader(original.Data), "image/jpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	upload := models.Upload{
+		UserID:      userID,
+		Backend:     h.storage.Name(),
+		Key:         key,
+		URL:         url,
+		ContentType: "image/jpeg",
+		Size:        int64(len(original.Data)),
+		Width:       original.Width,
+		Height:      original.Height,
+		Variants:    models.ImageVariants{Original: url, Full: url, Card: url, Thumb: url},
+	}
+
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&upload).Error; err != nil {
+			return err
+		}
+		return jobs.Enqueue(tx, jobs.KindImageVariants, imageVariantsPayload{UploadID: upload.ID}, jobs.Options{})
+	})
+	if err != nil {
+		h.deleteKeys(c.Request.Context(), []string{key})
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+type imageVariantsPayload struct {
+	UploadID uint `json:"uploadId"`
+}
+
+// GenerateVariants is the job that stores the resized variants of an upload
+// and points the upload, and any product already using it, at them. Running
+// it again overwrites the same keys.
+func (h *UploadHandler) GenerateVariants(ctx context.Context, job *models.Job) error {
+	var payload imageVariantsPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	var upload models.Upload
+	if err := repository.DB.WithContext(ctx).First(&upload, payload.UploadID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	src, err := h.storage.Open(ctx, upload.Key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(src)
+	src.Close()
+	if err != nil {
+		return err
+	}
+
+	renditions, err := imaging.Resize(data)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	variants := models.ImageVariants{Original: upload.URL}
+	base := strings.TrimSuffix(upload.Key, ".jpg")
+	for _, rendition := range renditions {
+		url, err := h.storage.Save(ctx, base+"-"+rendition.Name+".jpg", bytes.NewReader(rendition.Data), "image/jpeg")
+		if err != nil {
+			return err
+		}
+		switch rendition.Name {
+		case "full":
+			variants.Full = url
+		case "card":
+			variants.Card = url
+		case "thumb":
+			variants.Thumb = url
+		}
+	}
+
+	now := time.Now()
+	upload.Variants = variants
+	upload.ProcessedAt = &now
+
+	return repository.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&upload).Select("variants", "processed_at").Updates(&upload).Error; err != nil {
+			return err
+		}
+
+		// Listings created before the variants were ready hold the
+		// placeholders
+		var products []models.Product
+		err := tx.Unscoped().Select("id", "image_variants").
+			Where("images @> jsonb_build_array(?::text)", upload.URL).
+			Find(&products).Error
+		if err != nil {
+			return err
+		}
+		for i := range products {
+			for j := range products[i].ImageVariants {
+				if products[i].ImageVariants[j].Original == upload.URL {
+					products[i].ImageVariants[j] = variants
+				}
+			}
+			err := tx.Unscoped().Model(&products[i]).Select("image_variants").Updates(&products[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (h *UploadHandler) deleteKeys(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := h.storage.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
+
+func validateDimensions(width, height int) error {
+	minDim := config.AppConfig.ImageMinDimension
+	maxDim := config.AppConfig.ImageMaxDimension
+	if width < minDim || height < minDim {
+		return &imageValidationError{fmt.Sprintf("image must be at least %dx%d pixels", minDim, minDim)}
+	}
+	if width > maxDim || height > maxDim {
+		return &imageValidationError{fmt.Sprintf("image must be at most %dx%d pixels", maxDim, maxDim)}
+	}
+	return nil
+}
+
+func randomName() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// resolveImages checks that every image URL was issued by our storage for
+// this user and returns the variants of each, in order. Images the product
+// already has are accepted as-is, so existing listings keep them.
+func resolveImages(userID uint, images []string, existing []models.ImageVariants) ([]models.ImageVariants, error) {
+	known := make(map[string]models.ImageVariants, len(existing))
+	for _, variants := range existing {
+		known[variants.Original] = variants
+	}
+
+	var pending []string
+	for _, url := range images {
+		if _, ok := known[url]; !ok {
+			pending = append(pending, url)
+		}
+	}
+
+	if len(pending) > 0 {
+		var uploads []models.Upload
+		err := repository.DB.Where("user_id = ? AND url IN ?", userID, pending).Find(&uploads).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, upload := range uploads {
+			known[upload.URL] = upload.Variants
+		}
+	}
+
+	resolved := make([]models.ImageVariants, 0, len(images))
+	for _, url := range images {
+		variants, ok := known[url]
+		if !ok {
+			return nil, &imageValidationError{"images must be uploaded through /api/v1/uploads/images"}
+		}
+		resolved = append(resolved, variants)
+	}
+	return resolved, nil
+}
+
+// productImageVariants returns the variants of a product's current images.
+// Images stored before variants were generated fall back to the original URL.
+func productImageVariants(product models.Product) []models.ImageVariants {
+	byURL := make(map[string]models.ImageVariants, len(product.ImageVariants))
+	for _, variants := range product.ImageVariants {
+		byURL[variants.Original] = variants
+	}
+
+	result := make([]models.ImageVariants, 0, len(product.Images))
+	for _, url := range product.Images {
+		variants, ok := byURL[url]
+		if !ok {
+			variants = models.ImageVariants{Original: url, Full: url, Card: url, Thumb: url}
+		}
+		result = append(result, variants)
+	}
+	return result
+}
+
+func respondImageRefError(c *gin.Context, err error) {
+	var validationErr *imageValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify images"})
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UserHandler struct{}
+
+func NewUserHandler() *UserHandler {
+	return &UserHandler{}
+}
+
+// GetStorefront returns a seller's public profile and available listings.
+func (h *UserHandler) GetStorefront(c *gin.Context) {
+	seller, ok := loadPublicUser(c)
+	if !ok {
+		return
+	}
+
+	// Query parameters
+	page, limit := pageParams(c, 12)
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	query := repository.DB.Model(&models.Product{}).
+		Where("user_id = ? AND status = ?", seller.ID, models.ProductStatusAvailable)
+
+	// Count total results
+	var total int64
+	query.Count(&total)
+
+	var products []models.Product
+	result := query.
+		Preload("Category").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&products)
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"seller":     seller,
+		"products":   products,
+		"pagination": paginationInfo(page, limit, total),
+	})
+}
+
+// RevealPhone returns a seller's phone number to a signed-in user. Each user
+// can reveal a limited number of distinct numbers per window, which keeps
+// contact details from being scraped.
+func (h *UserHandler) RevealPhone(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.User
+	result := repository.DB.Where("is_active = ?", true).First(&user, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	viewerID := userID.(uint)
+	if user.ID == viewerID {
+		c.JSON(http.StatusOK, gin.H{"phone": user.PhoneNumber})
+		return
+	}
+
+	if user.PhoneNumber == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This user has not shared a phone number"})
+		return
+	}
+
+	blocked, err := usersBlocked(repository.DB, viewerID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot contact this user"})
+		return
+	}
+
+	limited := false
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialize reveals per viewer so concurrent requests cannot exceed the limit
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", viewerID).Error; err != nil {
+			return err
+		}
+
+		since := time.Now().Add(-config.AppConfig.PhoneRevealWindow)
+		var revealed []uint
+		err := tx.Model(&models.ContactReveal{}).
+			Where("viewer_id = ? AND created_at > ?", viewerID, since).
+			Distinct().Pluck("user_id", &revealed).Error
+		if err != nil {
+			return err
+		}
+
+		// Showing a number again within the window is free
+		for _, revealedID := range revealed {
+			if revealedID == user.ID {
+				return nil
+			}
+		}
+		if len(revealed) >= config.AppConfig.PhoneRevealLimit {
+			limited = true
+			return nil
+		}
+
+		return tx.Create(&models.ContactReveal{ViewerID: viewerID, UserID: user.ID}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if limited {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "You have viewed too many phone numbers recently. Please message the seller instead"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"phone": user.PhoneNumber})
+}
+
+// loadPublicUser loads the active user named by the :id parameter as a
+// PublicUser.
+func loadPublicUser(c *gin.Context) (*models.PublicUser, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+
+	var user models.User
+	result := repository.DB.Where("is_active = ?", true).First(&user, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	counts, err := activeListingCounts([]uint{user.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	public := user.Public()
+	public.ActiveListings = counts[user.ID]
+	return &public, true
+}
+
+// attachSellers sets the public seller view on products whose User was
+// preloaded.
+func attachSellers(products ...*models.Product) error {
+	var sellerIDs []uint
+	for _, product := range products {
+		if product.User.ID != 0 {
+			sellerIDs = append(sellerIDs, product.User.ID)
+		}
+	}
+	if len(sellerIDs) == 0 {
+		return nil
+	}
+
+	counts, err := activeListingCounts(sellerIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if product.User.ID == 0 {
+			continue
+		}
+		seller := product.User.Public()
+		seller.ActiveListings = counts[seller.ID]
+		product.Seller = &seller
+	}
+	return nil
+}
+
+// activeListingCounts counts the listed (available or reserved) products of
+// each user.
+func activeListingCounts(userIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		UserID uint
+		Count  int64
+	}
+	err := repository.DB.Model(&models.Product{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ? AND is_active = ?", userIDs, true).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, nil
+}
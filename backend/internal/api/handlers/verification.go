@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
+	"bech-do-backend/internal/services/jobs"
+	"bech-do-backend/internal/services/mailer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationPurpose = "email_verification"
+	verificationResendDelay  = time.Minute
+)
+
+type verificationClaims struct {
+	UserID  uint   `json:"user_id"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// generateVerificationToken signs a token bound to the user's current email,
+// so changing the email invalidates links sent to the old address.
+func generateVerificationToken(user models.User) (string, error) {
+	now := time.Now()
+	claims := &verificationClaims{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Purpose: emailVerificationPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(config.AppConfig.VerificationTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+func parseVerificationToken(tokenString string) (*verificationClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &verificationClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*verificationClaims)
+	if !ok || !token.Valid || claims.Purpose != emailVerificationPurpose {
+		return nil, errors.New("invalid verification token")
+	}
+	return claims, nil
+}
+
+func (h *AuthHandler) sendVerificationEmail(user models.User) error {
+	// The job signs the link, so the jobs table never holds a usable token
+	payload := verificationJob{UserID: user.ID}
+	if err := jobs.Enqueue(repository.DB, jobs.KindSendVerification, payload, jobs.Options{}); err != nil {
+		return err
+	}
+
+	return repository.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("verification_sent_at", time.Now()).Error
+}
+
+// verificationJob is the payload of a verification email job.
+type verificationJob struct {
+	UserID uint `json:"userId"`
+}
+
+// SendVerification returns the job handler that emails a user a link to
+// verify their current address. Users who verified in the meantime are
+// skipped.
+func SendVerification(m mailer.Mailer) jobs.Handler {
+	return func(ctx context.Context, job *models.Job) error {
+		var payload verificationJob
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+
+		var user models.User
+		result := repository.DB.WithContext(ctx).First(&user, payload.UserID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return result.Error
+		}
+		if user.IsVerified {
+			return nil
+		}
+
+		token, err := generateVerificationToken(user)
+		if err != nil {
+			return err
+		}
+
+		link := config.AppConfig.FrontendURL + "/verify-email?token=" + url.QueryEscape(token)
+		return m.Send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Verify your Bech-Do email address",
+			Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n\nIf you did not create a Bech-Do account, you can ignore this email.\n",
+				user.FirstName, link, config.AppConfig.VerificationTokenTTL),
+		})
+	}
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := parseVerificationToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	var user models.User
+	result := repository.DB.First(&user, claims.UserID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if user.Email != claims.Email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	if !user.IsVerified {
+		result = repository.DB.Model(&user).Update("is_verified", true)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+			return
+		}
+	}
+
+	// Remove password from response
+	user.Password = ""
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully", "user": user})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	result := repository.DB.First(&user, userID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if user.IsVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	if user.VerificationSentAt != nil && time.Since(*user.VerificationSentAt) < verificationResendDelay {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait a minute before requesting another verification email"})
+		return
+	}
+
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
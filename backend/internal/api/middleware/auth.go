@@ -1,21 +1,24 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"bech-do-backend/internal/config"
 	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID uint   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -36,49 +39,112 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Parse and validate the token
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.AppConfig.JWTSecret), nil
-		})
-
+		claims, err := ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-			c.Set("user_id", claims.UserID)
-			c.Set("user_email", claims.Email)
-			c.Set("user_role", claims.Role)
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
+		c.Set("user_id", claims.UserID)
+		c.Set("user_email", claims.Email)
+		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware identifies the user when a valid token is sent but
+// lets anonymous requests through. Invalid tokens are treated as anonymous.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString != "" {
+			if claims, err := ValidateToken(tokenString); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_email", claims.Email)
+				c.Set("user_role", claims.Role)
+				c.Set("session_id", claims.SessionID)
+			}
 		}
+		c.Next()
+	}
+}
+
+// ValidateToken parses an access token and checks that its session is still
+// active. The returned error message is safe to send to the client.
+func ValidateToken(tokenString string) (*Claims, error) {
+	// Parse and validate the token
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
+
+	if err != nil {
+		return nil, errors.New("Invalid token")
 	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("Invalid token claims")
+	}
+
+	// Reject tokens whose session was revoked or whose user was deactivated
+	if !SessionActive(claims.UserID, claims.SessionID) {
+		return nil, errors.New("Session has expired or been revoked")
+	}
+
+	return claims, nil
 }
 
-func AdminMiddleware() gin.HandlerFunc {
+// RequirePermission lets the request through only if the user's role grants
+// every one of the permissions. It must run after AuthMiddleware.
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("user_role")
-		if !exists || role != string(models.UserRoleAdmin) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-			c.Abort()
-			return
+		for _, permission := range permissions {
+			if !HasPermission(c, permission) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to do this"})
+				c.Abort()
+				return
+			}
 		}
 		c.Next()
 	}
 }
 
-func GenerateJWT(userID uint, email string, role models.UserRole) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token expires in 24 hours
+// HasPermission reports whether the authenticated user's role grants the
+// permission. Role changes revoke the user's sessions, so the role in the
+// token is current.
+func HasPermission(c *gin.Context, permission models.Permission) bool {
+	role, _ := c.Get("user_role")
+	name, _ := role.(string)
+	return models.UserRole(name).Can(permission)
+}
+
+// SessionActive reports whether the session is still valid and belongs to
+// the user, who must be active.
+func SessionActive(userID, sessionID uint) bool {
+	if sessionID == 0 {
+		return false
+	}
+
+	var count int64
+	err := repository.DB.Table("sessions").
+		Joins("JOIN users ON users.id = sessions.user_id").
+		Where("sessions.id = ? AND sessions.user_id = ?", sessionID, userID).
+		Where("sessions.revoked_at IS NULL AND sessions.expires_at > ?", time.Now()).
+		Where("users.is_active = ? AND users.deleted_at IS NULL", true).
+		Count(&count).Error
+	return err == nil && count > 0
+}
+
+func GenerateJWT(userID uint, email string, role models.UserRole, sessionID uint) (string, error) {
+	expirationTime := time.Now().Add(config.AppConfig.AccessTokenTTL)
 
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   string(role),
+		UserID:    userID,
+		Email:     email,
+		Role:      string(role),
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
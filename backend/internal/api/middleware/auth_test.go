@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bech-do-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		role        interface{}
+		permissions []models.Permission
+		wantCode    int
+	}{
+		{"granted", "admin", []models.Permission{models.PermissionUsersSuspend}, http.StatusOK},
+		{"all granted", "moderator", []models.Permission{models.PermissionProductsView, models.PermissionProductsModerate}, http.StatusOK},
+		{"one missing", "moderator", []models.Permission{models.PermissionProductsModerate, models.PermissionProductsManage}, http.StatusForbidden},
+		{"plain user", "user", []models.Permission{models.PermissionDashboardView}, http.StatusForbidden},
+		{"no role", nil, []models.Permission{models.PermissionDashboardView}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) {
+			if tt.role != nil {
+				c.Set("user_role", tt.role)
+			}
+		}, RequirePermission(tt.permissions...), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantCode)
+		}
+	}
+}
@@ -3,6 +3,10 @@ package routes
 import (
 	"bech-do-backend/internal/api/handlers"
 	"bech-do-backend/internal/api/middleware"
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/realtime"
+	"bech-do-backend/internal/services/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,13 +17,37 @@ func SetupRoutes(r *gin.Engine) {
 	productHandler := handlers.NewProductHandler()
 	categoryHandler := handlers.NewCategoryHandler()
 	healthHandler := handlers.NewHealthHandler()
+	conversationHandler := handlers.NewConversationHandler()
+	offerHandler := handlers.NewOfferHandler()
+	transactionHandler := handlers.NewTransactionHandler()
+	reviewHandler := handlers.NewReviewHandler()
+	userHandler := handlers.NewUserHandler()
+	favoriteHandler := handlers.NewFavoriteHandler()
+	savedSearchHandler := handlers.NewSavedSearchHandler()
+	notificationHandler := handlers.NewNotificationHandler()
+	moderationHandler := handlers.NewModerationHandler()
+	adminHandler := handlers.NewAdminHandler()
+	auditHandler := handlers.NewAuditHandler()
+
+	store := storage.New(config.AppConfig)
+	uploadHandler := handlers.NewUploadHandler(store)
+	realtimeHandler := handlers.NewRealtimeHandler(realtime.Default())
 
 	// Health check
 	r.GET("/health", healthHandler.HealthCheck)
 
+	// Serve locally stored uploads
+	if store.Name() == "local" {
+		r.Static(storage.MediaPrefix, config.AppConfig.UploadDir)
+	}
+
 	// API v1 routes
 	v1 := r.Group("/api/v1")
 
+	// Realtime events; authenticates from the header or a single-use ticket
+	v1.GET("/ws", realtimeHandler.Connect)
+	v1.POST("/ws/ticket", middleware.AuthMiddleware(), realtimeHandler.IssueTicket)
+
 	// Public routes
 	public := v1.Group("/")
 	{
@@ -28,13 +56,18 @@ func SetupRoutes(r *gin.Engine) {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/verify-email", authHandler.VerifyEmail)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
 		}
 
 		// Product routes (public)
 		products := public.Group("products")
 		{
-			products.GET("/", productHandler.GetProducts)
-			products.GET("/:id", productHandler.GetProduct)
+			products.GET("/", middleware.OptionalAuthMiddleware(), productHandler.GetProducts)
+			products.GET("/:id", middleware.OptionalAuthMiddleware(), productHandler.GetProduct)
 		}
 
 		// Category routes
@@ -43,12 +76,25 @@ func SetupRoutes(r *gin.Engine) {
 			categories.GET("/", categoryHandler.GetCategories)
 			categories.GET("/stats", categoryHandler.GetCategoryStats)
 		}
+
+		// Public user routes
+		users := public.Group("users")
+		{
+			users.GET("/:id", userHandler.GetStorefront)
+			users.GET("/:id/reviews", reviewHandler.GetUserReviews)
+		}
 	}
 
 	// Protected routes (require authentication)
 	protected := v1.Group("/")
 	protected.Use(middleware.AuthMiddleware())
 	{
+		// Auth routes (protected)
+		auth := protected.Group("auth")
+		{
+			auth.POST("/resend-verification", authHandler.ResendVerification)
+		}
+
 		// User profile routes
 		user := protected.Group("user")
 		{
@@ -63,6 +109,93 @@ func SetupRoutes(r *gin.Engine) {
 			products.POST("/", productHandler.CreateProduct)
 			products.PUT("/:id", productHandler.UpdateProduct)
 			products.DELETE("/:id", productHandler.DeleteProduct)
+			products.POST("/:id/publish", productHandler.Publish)
+			products.POST("/:id/reserve", productHandler.Reserve)
+			products.POST("/:id/mark-sold", productHandler.MarkSold)
+			products.POST("/:id/relist", productHandler.Relist)
+			products.POST("/:id/hide", productHandler.Hide)
+			products.POST("/:id/renew", productHandler.Renew)
+			products.POST("/:id/report", moderationHandler.ReportProduct)
+			products.POST("/:id/offers", offerHandler.CreateOffer)
+		}
+
+		// Uploads
+		uploads := protected.Group("uploads")
+		{
+			uploads.POST("/images", uploadHandler.UploadImages)
+		}
+
+		// Messaging
+		conversations := protected.Group("conversations")
+		{
+			conversations.GET("/", conversationHandler.GetConversations)
+			conversations.POST("/", conversationHandler.StartConversation)
+			conversations.GET("/unread-count", conversationHandler.GetUnreadCount)
+			conversations.GET("/:id", conversationHandler.GetConversation)
+			conversations.POST("/:id/messages", conversationHandler.SendMessage)
+			conversations.POST("/:id/read", conversationHandler.MarkRead)
+		}
+
+		// Offers
+		offers := protected.Group("offers")
+		{
+			offers.GET("/sent", offerHandler.GetSentOffers)
+			offers.GET("/received", offerHandler.GetReceivedOffers)
+			offers.GET("/:id", offerHandler.GetOffer)
+			offers.POST("/:id/accept", offerHandler.AcceptOffer)
+			offers.POST("/:id/reject", offerHandler.RejectOffer)
+			offers.POST("/:id/counter", offerHandler.CounterOffer)
+		}
+
+		// Favorites
+		favorites := protected.Group("favorites")
+		{
+			favorites.GET("/", favoriteHandler.GetFavorites)
+			favorites.POST("/:productId", favoriteHandler.AddFavorite)
+			favorites.DELETE("/:productId", favoriteHandler.RemoveFavorite)
+		}
+
+		// Saved searches
+		savedSearches := protected.Group("saved-searches")
+		{
+			savedSearches.GET("/", savedSearchHandler.GetSavedSearches)
+			savedSearches.POST("/", savedSearchHandler.CreateSavedSearch)
+			savedSearches.PUT("/:id", savedSearchHandler.UpdateSavedSearch)
+			savedSearches.DELETE("/:id", savedSearchHandler.DeleteSavedSearch)
+			savedSearches.GET("/:id/matches", savedSearchHandler.GetSavedSearchMatches)
+		}
+
+		// Notifications
+		notifications := protected.Group("notifications")
+		{
+			notifications.GET("/", notificationHandler.GetNotifications)
+			notifications.POST("/read-all", notificationHandler.MarkAllRead)
+			notifications.GET("/preferences", notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+			notifications.POST("/:id/read", notificationHandler.MarkRead)
+		}
+
+		// Transactions
+		transactions := protected.Group("transactions")
+		{
+			transactions.GET("/purchases", transactionHandler.GetPurchases)
+			transactions.GET("/sales", transactionHandler.GetSales)
+			transactions.GET("/:id", transactionHandler.GetTransaction)
+			transactions.POST("/:id/confirm", transactionHandler.ConfirmReceipt)
+			transactions.POST("/:id/review", reviewHandler.CreateReview)
+		}
+
+		// Reviews
+		reviews := protected.Group("reviews")
+		{
+			reviews.POST("/:id/reply", reviewHandler.ReplyToReview)
+		}
+
+		users := protected.Group("users")
+		{
+			users.POST("/:id/phone", userHandler.RevealPhone)
+			users.POST("/:id/block", conversationHandler.BlockUser)
+			users.DELETE("/:id/block", conversationHandler.UnblockUser)
 		}
 
 		// My products
@@ -72,21 +205,38 @@ func SetupRoutes(r *gin.Engine) {
 		}
 	}
 
-	// Admin routes (require admin role)
+	// Staff routes; each requires the permissions its role must grant
 	admin := v1.Group("/admin")
 	admin.Use(middleware.AuthMiddleware())
-	admin.Use(middleware.AdminMiddleware())
 	{
-		admin.GET("/users", func(c *gin.Context) {
-			c.JSON(200, gin.H{"message": "Admin users endpoint - coming soon"})
-		})
+		admin.GET("/dashboard", middleware.RequirePermission(models.PermissionDashboardView), adminHandler.GetDashboard)
+
+		// Users
+		admin.GET("/users", middleware.RequirePermission(models.PermissionUsersView), adminHandler.GetUsers)
+		admin.GET("/users/:id", middleware.RequirePermission(models.PermissionUsersView), adminHandler.GetUser)
+		admin.POST("/users/:id/suspend", middleware.RequirePermission(models.PermissionUsersSuspend), adminHandler.SuspendUser)
+		admin.POST("/users/:id/reactivate", middleware.RequirePermission(models.PermissionUsersSuspend), adminHandler.ReactivateUser)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(models.PermissionUsersRoles), adminHandler.ChangeRole)
+		admin.GET("/login-lockouts", middleware.RequirePermission(models.PermissionUsersView), adminHandler.GetLoginLockouts)
+		admin.POST("/login-lockouts/:id/unlock", middleware.RequirePermission(models.PermissionUsersSuspend), adminHandler.UnlockLogin)
+
+		// Products
+		admin.GET("/products", middleware.RequirePermission(models.PermissionProductsView), adminHandler.GetProducts)
+		admin.POST("/products/:id/restore", middleware.RequirePermission(models.PermissionProductsManage), adminHandler.RestoreProduct)
 
-		admin.GET("/products", func(c *gin.Context) {
-			c.JSON(200, gin.H{"message": "Admin products endpoint - coming soon"})
-		})
+		// Moderation
+		moderation := admin.Group("", middleware.RequirePermission(models.PermissionProductsModerate))
+		{
+			moderation.GET("/moderation/queue", moderationHandler.GetQueue)
+			moderation.GET("/reports", moderationHandler.GetReports)
+			moderation.POST("/reports/:id/dismiss", moderationHandler.DismissReport)
+			moderation.POST("/products/:id/approve", moderationHandler.Approve)
+			moderation.POST("/products/:id/take-down", moderationHandler.TakeDown)
+			moderation.POST("/products/:id/remove", moderationHandler.Remove)
+		}
 
-		admin.GET("/dashboard", func(c *gin.Context) {
-			c.JSON(200, gin.H{"message": "Admin dashboard endpoint - coming soon"})
-		})
+		// Audit log
+		admin.GET("/audit-log", middleware.RequirePermission(models.PermissionAuditView), auditHandler.GetAuditLog)
+		admin.GET("/audit-log/verify", middleware.RequirePermission(models.PermissionAuditView), auditHandler.VerifyAuditLog)
 	}
 }
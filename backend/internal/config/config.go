@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +23,67 @@ type Config struct {
 	CloudinarySecret   string
 	FrontendURL        string
 	Environment        string
+	TrustedProxies     []string
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+
+	// Login protection
+	LoginFailureWindow   time.Duration
+	LoginDelayAfter      int
+	LoginLockoutAfter    int
+	LoginIPDelayAfter    int
+	LoginIPLockoutAfter  int
+	LoginBaseDelay       time.Duration
+	LoginMaxDelay        time.Duration
+	LoginLockoutDuration time.Duration
+
+	// Email
+	MailDriver            string
+	MailFrom              string
+	MailOutputDir         string
+	SMTPHost              string
+	SMTPPort              string
+	SMTPUsername          string
+	SMTPPassword          string
+	VerificationTokenTTL  time.Duration
+	PasswordResetTTL      time.Duration
+	RequireVerifiedSeller bool
+
+	// Marketplace
+	OfferTTL          time.Duration
+	PhoneRevealLimit  int
+	PhoneRevealWindow time.Duration
+
+	// Listing expiry
+	ListingTTL           time.Duration
+	ListingExpiryWarning time.Duration
+	ListingMaxRenewals   int
+
+	// Moderation
+	ReviewNewSellers  bool
+	NewSellerListings int
+
+	// Saved searches
+	SavedSearchLimit    int
+	SavedSearchInterval time.Duration
+
+	// Uploads
+	StorageDriver     string
+	UploadDir         string
+	PublicBaseURL     string
+	MaxUploadSize     int64
+	ImageMinDimension int
+	ImageMaxDimension int
+
+	// Realtime
+	RealtimeDriver      string
+	RealtimeDatabaseURL string
+
+	// Background jobs
+	JobsInProcess   bool
+	JobWorkers      int
+	JobPollInterval time.Duration
+	JobRetention    time.Duration
 }
 
 var AppConfig *Config
@@ -45,6 +109,58 @@ func LoadConfig() *Config {
 		CloudinarySecret:   getEnv("CLOUDINARY_API_SECRET", ""),
 		FrontendURL:        getEnv("FRONTEND_URL", "http://localhost:3000"),
 		Environment:        getEnv("ENV", "development"),
+		TrustedProxies:     getEnvList("TRUSTED_PROXIES"),
+		AccessTokenTTL:     getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:    getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		LoginFailureWindow:   getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginDelayAfter:      getEnvInt("LOGIN_DELAY_AFTER", 3),
+		LoginLockoutAfter:    getEnvInt("LOGIN_LOCKOUT_AFTER", 10),
+		LoginIPDelayAfter:    getEnvInt("LOGIN_IP_DELAY_AFTER", 20),
+		LoginIPLockoutAfter:  getEnvInt("LOGIN_IP_LOCKOUT_AFTER", 100),
+		LoginBaseDelay:       getEnvDuration("LOGIN_BASE_DELAY", time.Second),
+		LoginMaxDelay:        getEnvDuration("LOGIN_MAX_DELAY", time.Minute),
+		LoginLockoutDuration: getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+
+		MailDriver:            getEnv("MAIL_DRIVER", "log"),
+		MailFrom:              getEnv("MAIL_FROM", "Bech-Do <no-reply@bechdo.com>"),
+		MailOutputDir:         getEnv("MAIL_OUTPUT_DIR", ""),
+		SMTPHost:              getEnv("SMTP_HOST", ""),
+		SMTPPort:              getEnv("SMTP_PORT", "587"),
+		SMTPUsername:          getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:          getEnv("SMTP_PASSWORD", ""),
+		VerificationTokenTTL:  getEnvDuration("VERIFICATION_TOKEN_TTL", 48*time.Hour),
+		PasswordResetTTL:      getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		RequireVerifiedSeller: getEnvBool("REQUIRE_VERIFIED_SELLER", true),
+
+		OfferTTL:          getEnvDuration("OFFER_TTL", 48*time.Hour),
+		PhoneRevealLimit:  getEnvInt("PHONE_REVEAL_LIMIT", 10),
+		PhoneRevealWindow: getEnvDuration("PHONE_REVEAL_WINDOW", 24*time.Hour),
+
+		ListingTTL:           getEnvDuration("LISTING_TTL", 30*24*time.Hour),
+		ListingExpiryWarning: getEnvDuration("LISTING_EXPIRY_WARNING", 3*24*time.Hour),
+		ListingMaxRenewals:   getEnvInt("LISTING_MAX_RENEWALS", 3),
+
+		ReviewNewSellers:  getEnvBool("REVIEW_NEW_SELLERS", false),
+		NewSellerListings: getEnvInt("NEW_SELLER_LISTINGS", 3),
+
+		SavedSearchLimit:    getEnvInt("SAVED_SEARCH_LIMIT", 10),
+		SavedSearchInterval: getEnvDuration("SAVED_SEARCH_INTERVAL", 5*time.Minute),
+
+		StorageDriver:     getEnv("STORAGE_DRIVER", "local"),
+		UploadDir:         getEnv("UPLOAD_DIR", "./uploads"),
+		PublicBaseURL:     getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+		MaxUploadSize:     int64(getEnvInt("MAX_UPLOAD_SIZE_MB", 10)) << 20,
+		ImageMinDimension: getEnvInt("IMAGE_MIN_DIMENSION", 200),
+		ImageMaxDimension: getEnvInt("IMAGE_MAX_DIMENSION", 8000),
+
+		RealtimeDriver:      getEnv("REALTIME_DRIVER", "memory"),
+		RealtimeDatabaseURL: getEnv("REALTIME_DATABASE_URL", ""),
+
+		JobsInProcess:   getEnvBool("JOBS_IN_PROCESS", true),
+		JobWorkers:      getEnvInt("JOB_WORKERS", 4),
+		JobPollInterval: getEnvDuration("JOB_POLL_INTERVAL", time.Second),
+		JobRetention:    getEnvDuration("JOB_RETENTION", 7*24*time.Hour),
 	}
 
 	AppConfig = config
@@ -57,3 +173,53 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList splits a comma-separated variable, dropping empty items.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s: %q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s: %q, using default %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s: %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// AuditEntry records one privileged or security-relevant action. Entries
+// are append-only: the database rejects updates and deletes, and each entry
+// carries the hash of the one before it so that tampering breaks the chain.
+type AuditEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// ActorID is empty for actions taken by the system
+	ActorID    *uint  `json:"actorId,omitempty" gorm:"index"`
+	ActorEmail string `json:"actorEmail,omitempty"`
+	Action     string `json:"action" gorm:"not null;index"`
+	TargetType string `json:"targetType,omitempty"`
+	TargetID   *uint  `json:"targetId,omitempty"`
+	// Changes maps each changed field to its before and after values, and
+	// may carry details such as a reason
+	Changes   map[string]interface{} `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	IP        string                 `json:"ip,omitempty"`
+	UserAgent string                 `json:"userAgent,omitempty"`
+
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash" gorm:"uniqueIndex;not null"`
+}
+
+// Audit actions
+const (
+	AuditPasswordChange  = "password.change"
+	AuditPasswordReset   = "password.reset"
+	AuditUserSuspend     = "user.suspend"
+	AuditUserReactivate  = "user.reactivate"
+	AuditUserRoleChange  = "user.role_change"
+	AuditProductDelete   = "product.delete"
+	AuditProductRestore  = "product.restore"
+	AuditProductApprove  = "product.approve"
+	AuditProductTakeDown = "product.take_down"
+	AuditProductRemove   = "product.remove"
+	AuditReportDismiss   = "report.dismiss"
+	AuditLoginLockout    = "login.lockout"
+	AuditLoginUnlock     = "login.unlock"
+)
+
+// Audit target types
+const (
+	AuditTargetUser    = "user"
+	AuditTargetProduct = "product"
+	AuditTargetReport  = "report"
+	// AuditTargetLoginThrottle is an email or IP tracked for failed sign-ins
+	AuditTargetLoginThrottle = "login_throttle"
+)
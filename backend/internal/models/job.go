@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job is a unit of background work run by the job runner. Failed jobs are
+// retried with backoff until MaxAttempts, after which they are dead and kept
+// for inspection.
+type Job struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Kind        string     `json:"kind" gorm:"not null"`
+	Payload     string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status      JobStatus  `json:"status" gorm:"default:'pending'"`
+	Attempts    int        `json:"attempts" gorm:"default:0"`
+	MaxAttempts int        `json:"maxAttempts" gorm:"default:5"`
+	RunAt       time.Time  `json:"runAt" gorm:"not null"`
+	LockedAt    *time.Time `json:"lockedAt,omitempty"`
+	LockedBy    string     `json:"lockedBy,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// UniqueKey deduplicates jobs, e.g. one run per cron slot
+	UniqueKey *string `json:"uniqueKey,omitempty" gorm:"uniqueIndex"`
+}
+
+// Decode unmarshals the payload into v.
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "pending"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	// JobStatusDead jobs ran out of attempts
+	JobStatusDead JobStatus = "dead"
+)
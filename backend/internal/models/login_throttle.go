@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// LoginThrottle counts recent failed sign-ins for one email address or one
+// client IP. Emails are tracked whether or not an account exists, so
+// throttling does not reveal which addresses are registered.
+type LoginThrottle struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Kind          LoginThrottleKind `json:"kind" gorm:"not null;uniqueIndex:idx_login_throttles_kind_key"`
+	Key           string            `json:"key" gorm:"not null;uniqueIndex:idx_login_throttles_kind_key"`
+	Failures      int               `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time         `json:"lastFailureAt"`
+	// BlockedUntil rejects sign-ins before it, as a delay after repeated
+	// failures or as a lockout
+	BlockedUntil *time.Time `json:"blockedUntil,omitempty"`
+	// LockedAt is set while the key is locked out rather than just delayed
+	LockedAt *time.Time `json:"lockedAt,omitempty"`
+}
+
+type LoginThrottleKind string
+
+const (
+	LoginThrottleEmail LoginThrottleKind = "email"
+	LoginThrottleIP    LoginThrottleKind = "ip"
+)
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Conversation is a thread between a buyer and the seller of a product.
+// There is at most one conversation per product and buyer.
+type Conversation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ProductID     uint       `json:"productId" gorm:"not null;uniqueIndex:idx_conversations_product_buyer"`
+	BuyerID       uint       `json:"buyerId" gorm:"not null;uniqueIndex:idx_conversations_product_buyer"`
+	SellerID      uint       `json:"sellerId" gorm:"not null;index"`
+	LastMessageAt *time.Time `json:"lastMessageAt,omitempty"`
+
+	// Relationships
+	Product  Product   `json:"product,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Buyer    User      `json:"buyer,omitempty" gorm:"foreignKey:BuyerID"`
+	Seller   User      `json:"seller,omitempty" gorm:"foreignKey:SellerID"`
+	Messages []Message `json:"messages,omitempty"`
+
+	// Computed for the requesting participant
+	UnreadCount int64    `json:"unreadCount" gorm:"->;-:migration"`
+	LastMessage *Message `json:"lastMessage,omitempty" gorm:"-"`
+}
+
+type Message struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	ConversationID uint       `json:"conversationId" gorm:"not null;index"`
+	SenderID       uint       `json:"senderId" gorm:"not null"`
+	Body           string     `json:"body" gorm:"type:text;not null"`
+	ReadAt         *time.Time `json:"readAt,omitempty"`
+}
+
+// UserBlock stops two users from messaging each other. A block applies in
+// both directions.
+type UserBlock struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	BlockerID uint `json:"blockerId" gorm:"not null;uniqueIndex:idx_user_blocks_pair"`
+	BlockedID uint `json:"blockedId" gorm:"not null;uniqueIndex:idx_user_blocks_pair"`
+}
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"bech-do-backend/internal/services/geo"
 
 	"gorm.io/gorm"
 )
@@ -26,16 +30,106 @@ type User struct {
 	IsActive    bool     `json:"is_active" gorm:"default:true"`
 	Role        UserRole `json:"role" gorm:"default:'user'"`
 
+	// Set while an admin has suspended the account (IsActive is false)
+	SuspendedAt      *time.Time `json:"suspendedAt,omitempty"`
+	SuspensionReason string     `json:"suspensionReason,omitempty"`
+
+	// Aggregate of the reviews the user has received
+	Rating      float64 `json:"rating" gorm:"default:0"`
+	ReviewCount int     `json:"reviewCount" gorm:"default:0"`
+
+	VerificationSentAt *time.Time `json:"-"`
+
+	// Served through the notification preferences endpoints
+	NotificationPreferences NotificationPreferences `json:"-" gorm:"type:jsonb"`
+
 	// Relationships
 	Products []Product `json:"products,omitempty" gorm:"foreignKey:UserID"`
 }
 
-type UserRole string
+// PublicUser is the view of a user that can be shown to anyone. It carries
+// no contact details; those are only revealed on request.
+type PublicUser struct {
+	ID             uint      `json:"id"`
+	DisplayName    string    `json:"displayName"`
+	City           string    `json:"city,omitempty"`
+	MemberSince    time.Time `json:"memberSince"`
+	IsVerified     bool      `json:"isVerified"`
+	Rating         float64   `json:"rating"`
+	ReviewCount    int       `json:"reviewCount"`
+	ActiveListings int64     `json:"activeListings"`
+}
+
+// Public returns the public view of the user. ActiveListings is left for the
+// caller to fill in.
+func (u *User) Public() PublicUser {
+	return PublicUser{
+		ID:          u.ID,
+		DisplayName: u.DisplayName(),
+		City:        u.City,
+		MemberSince: u.CreatedAt,
+		IsVerified:  u.IsVerified,
+		Rating:      u.Rating,
+		ReviewCount: u.ReviewCount,
+	}
+}
 
-const (
-	UserRoleAdmin UserRole = "admin"
-	UserRoleUser  UserRole = "user"
-)
+// DisplayName is the user's first name and last initial, falling back to the
+// username.
+func (u *User) DisplayName() string {
+	first := strings.TrimSpace(u.FirstName)
+	if first == "" {
+		return u.Username
+	}
+	if last := strings.TrimSpace(u.LastName); last != "" {
+		initial, _ := utf8.DecodeRuneInString(last)
+		return first + " " + string(initial) + "."
+	}
+	return first
+}
+
+// Session tracks a refresh token issued at login. Access tokens carry the
+// session ID so that revoking a session invalidates them immediately.
+type Session struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID            uint       `json:"userId" gorm:"not null;index"`
+	RefreshTokenHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	PreviousTokenHash string     `json:"-" gorm:"index"`
+	UserAgent         string     `json:"userAgent"`
+	IPAddress         string     `json:"ipAddress"`
+	ExpiresAt         time.Time  `json:"expiresAt" gorm:"not null"`
+	LastUsedAt        *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty"`
+}
+
+// PasswordResetToken is a single-use token issued by the forgot-password flow.
+// Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	UserID    uint       `json:"userId" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	IPAddress string     `json:"ipAddress"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+// RealtimeTicket lets a browser open the realtime WebSocket, which cannot
+// carry an Authorization header, without putting its access token in the
+// URL. Tickets are single-use and short-lived; only their hash is stored.
+type RealtimeTicket struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	UserID    uint      `json:"userId" gorm:"not null;index"`
+	SessionID uint      `json:"sessionId" gorm:"not null"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null"`
+}
 
 type Category struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
@@ -48,6 +142,8 @@ type Category struct {
 	Description string `json:"description"`
 	Icon        string `json:"icon"`
 	IsActive    bool   `json:"isActive" gorm:"default:true"`
+	// ListingDays overrides how long listings in the category stay up
+	ListingDays *int `json:"listingDays,omitempty"`
 
 	// Relationships
 	Products []Product `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
@@ -59,45 +155,152 @@ type Product struct {
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
-	Title        string        `json:"title" gorm:"not null"`
-	Description  string        `json:"description" gorm:"type:text"`
-	Price        float64       `json:"price" gorm:"not null"`
-	Images       []string      `json:"images" gorm:"serializer:json"`
-	Condition    string        `json:"condition" gorm:"not null"`
-	Status       ProductStatus `json:"status" gorm:"default:'available'"`
-	Location     string        `json:"location"`
-	IsNegotiable bool          `json:"is_negotiable" gorm:"default:false"`
-	Views        int           `json:"viewsCount" gorm:"default:0"`
-	IsSold       bool          `json:"isSold" gorm:"default:false"`
-	IsActive     bool          `json:"isActive" gorm:"default:true"`
-	SoldAt       *time.Time    `json:"soldAt,omitempty"`
+	Title         string          `json:"title" gorm:"not null"`
+	Description   string          `json:"description" gorm:"type:text"`
+	Price         float64         `json:"price" gorm:"not null"`
+	Images        []string        `json:"images" gorm:"serializer:json"`
+	ImageVariants []ImageVariants `json:"imageVariants" gorm:"serializer:json"`
+	Condition     string          `json:"condition" gorm:"not null"`
+	Status        ProductStatus   `json:"status" gorm:"default:'available'"`
+	Location      string          `json:"location"`
+	PinCode       string          `json:"-"` // locates the listing; never exposed
+	Latitude      *float64        `json:"-"`
+	Longitude     *float64        `json:"-"`
+	IsNegotiable  bool            `json:"is_negotiable" gorm:"default:false"`
+	Views         int             `json:"viewsCount" gorm:"default:0"`
+	IsSold        bool            `json:"isSold" gorm:"default:false"`  // status is sold
+	IsActive      bool            `json:"isActive" gorm:"default:true"` // status is available or reserved
+	SoldAt        *time.Time      `json:"soldAt,omitempty"`
+	ListedAt      *time.Time      `json:"listedAt,omitempty"` // last published or re-listed
+	ReservedForID *uint           `json:"reservedForId,omitempty"`
+	FavoriteCount int             `json:"favoriteCount" gorm:"default:0"`
+
+	// Listing expiry
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	RenewalCount   int        `json:"renewalCount" gorm:"default:0"`
+	ExpiryWarnedAt *time.Time `json:"-"`
+
+	// Set while a moderator has hidden or removed the listing; the seller
+	// cannot relist it until a moderator approves it again
+	ModeratedAt      *time.Time `json:"moderatedAt,omitempty"`
+	ModerationReason string     `json:"moderationReason,omitempty"`
 
 	// Foreign Keys
 	UserID     uint `json:"userId" gorm:"not null"`
 	CategoryID uint `json:"categoryId" gorm:"not null"`
 
+	// Search results only
+	SearchRank         float64 `json:"searchRank,omitempty" gorm:"->;-:migration"`
+	TitleHighlight     string  `json:"titleHighlight,omitempty" gorm:"->;-:migration"`
+	DescriptionSnippet string  `json:"descriptionSnippet,omitempty" gorm:"->;-:migration"`
+
+	// Location searches only
+	DistanceKm *float64 `json:"distanceKm,omitempty" gorm:"->;-:migration"`
+
+	// Coarse location derived from the stored coordinates
+	Area *GeoPoint `json:"area,omitempty" gorm:"-"`
+
+	// Whether the requesting user favorited the product
+	IsFavorited bool `json:"isFavorited" gorm:"-"`
+
+	// Public view of the seller, filled in from User by the handlers
+	Seller *PublicUser `json:"user,omitempty" gorm:"-"`
+
 	// Relationships
-	User     User     `json:"user,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	User     User     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Category Category `json:"category,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
+// GeoPoint is a latitude/longitude pair.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// AfterFind exposes a rounded area (roughly a 1 km grid) in place of the
+// stored coordinates, which are never serialized.
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	if p.Latitude != nil && p.Longitude != nil {
+		area := geo.Round(geo.Point{Lat: *p.Latitude, Lng: *p.Longitude}, 2)
+		p.Area = &GeoPoint{Lat: area.Lat, Lng: area.Lng}
+	}
+	return nil
+}
+
+// ProductStatus is a product's place in its lifecycle. Status changes go
+// through the lifecycle service, which enforces the allowed transitions.
 type ProductStatus string
 
 const (
-	ProductStatusAvailable ProductStatus = "available"
-	ProductStatusSold      ProductStatus = "sold"
-	ProductStatusHidden    ProductStatus = "hidden"
+	ProductStatusDraft ProductStatus = "draft"
+	// ProductStatusPendingReview listings wait for a moderator to approve them
+	ProductStatusPendingReview ProductStatus = "pending_review"
+	ProductStatusAvailable     ProductStatus = "available"
+	ProductStatusReserved      ProductStatus = "reserved"
+	ProductStatusSold          ProductStatus = "sold"
+	ProductStatusHidden        ProductStatus = "hidden"
+	ProductStatusExpired       ProductStatus = "expired"
+	ProductStatusRemoved       ProductStatus = "removed"
 )
 
-type Admin struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+// IsListed reports whether the product is on the market. It backs the
+// is_active column.
+func (s ProductStatus) IsListed() bool {
+	return s == ProductStatusAvailable || s == ProductStatusReserved
+}
+
+// IsPublic reports whether anyone other than the seller can view the product.
+func (s ProductStatus) IsPublic() bool {
+	return s != ProductStatusDraft && s != ProductStatusPendingReview &&
+		s != ProductStatusHidden && s != ProductStatusRemoved
+}
+
+// Upload records a file stored through the storage backend. Product images
+// must reference an upload owned by the seller.
+type Upload struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	UserID      uint   `json:"userId" gorm:"not null;index"`
+	Backend     string `json:"backend" gorm:"not null"`
+	Key         string `json:"key" gorm:"not null"`
+	URL         string `json:"url" gorm:"uniqueIndex;not null"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+
+	// Variants point at the original until a job has generated them
+	Variants    ImageVariants `json:"variants" gorm:"serializer:json"`
+	ProcessedAt *time.Time    `json:"processedAt,omitempty"`
+}
+
+// Favorite is a product a user saved to their wishlist.
+type Favorite struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	UserID    uint `json:"userId" gorm:"not null;uniqueIndex:idx_favorites_user_product"`
+	ProductID uint `json:"productId" gorm:"not null;uniqueIndex:idx_favorites_user_product"`
+
+	// Relationships
+	Product Product `json:"product,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// ContactReveal records that a user viewed another user's phone number.
+// Reveals are rate limited per viewer.
+type ContactReveal struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	ViewerID uint `json:"viewerId" gorm:"not null;index"`
+	UserID   uint `json:"userId" gorm:"not null"`
+}
 
-	Email    string `json:"email" gorm:"uniqueIndex;not null"`
-	Password string `json:"-" gorm:"not null"`
-	Name     string `json:"name" gorm:"not null"`
-	Role     string `json:"role" gorm:"default:'admin'"`
-	IsActive bool   `json:"is_active" gorm:"default:true"`
+// ImageVariants lists the URLs of the renditions generated for one image.
+type ImageVariants struct {
+	Original string `json:"original"`
+	Full     string `json:"full"`
+	Card     string `json:"card"`
+	Thumb    string `json:"thumb"`
 }
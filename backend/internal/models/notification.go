@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Notification is an entry in a user's notification center.
+type Notification struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	UserID uint                   `json:"userId" gorm:"not null;index"`
+	Type   NotificationType       `json:"type" gorm:"not null"`
+	Title  string                 `json:"title" gorm:"not null"`
+	Body   string                 `json:"body"`
+	Link   string                 `json:"link,omitempty"` // frontend path
+	Data   map[string]interface{} `json:"data,omitempty" gorm:"type:jsonb;serializer:json"`
+	ReadAt *time.Time             `json:"readAt,omitempty"`
+}
+
+// NotificationType is what a notification is about. Preferences are kept per
+// type.
+type NotificationType string
+
+const (
+	NotificationTypeMessage         NotificationType = "message"
+	NotificationTypeOfferReceived   NotificationType = "offer_received"
+	NotificationTypeListingSold     NotificationType = "listing_sold"
+	NotificationTypePriceDrop       NotificationType = "price_drop"
+	NotificationTypeListingExpiring NotificationType = "listing_expiring"
+	NotificationTypeModeration      NotificationType = "moderation"
+	NotificationTypeSavedSearch     NotificationType = "saved_search"
+)
+
+// ChannelPreference says where notifications of one type are delivered.
+type ChannelPreference struct {
+	InApp bool `json:"inApp"`
+	Email bool `json:"email"`
+}
+
+// defaultNotificationPreferences apply to types the user has not configured.
+// Every type reaches the inbox; chatty types stay out of email by default.
+var defaultNotificationPreferences = map[NotificationType]ChannelPreference{
+	NotificationTypeMessage:         {InApp: true, Email: false},
+	NotificationTypeOfferReceived:   {InApp: true, Email: true},
+	NotificationTypeListingSold:     {InApp: true, Email: true},
+	NotificationTypePriceDrop:       {InApp: true, Email: false},
+	NotificationTypeListingExpiring: {InApp: true, Email: true},
+	NotificationTypeModeration:      {InApp: true, Email: true},
+	NotificationTypeSavedSearch:     {InApp: true, Email: true},
+}
+
+// IsValid reports whether the type is known.
+func (t NotificationType) IsValid() bool {
+	_, ok := defaultNotificationPreferences[t]
+	return ok
+}
+
+// NotificationPreferences holds the channels a user chose per notification
+// type. Only the types the user changed are stored.
+type NotificationPreferences map[NotificationType]ChannelPreference
+
+// For returns the channels for a type, falling back to the default.
+func (p NotificationPreferences) For(t NotificationType) ChannelPreference {
+	if pref, ok := p[t]; ok {
+		return pref
+	}
+	return defaultNotificationPreferences[t]
+}
+
+// Effective returns the channels for every type, defaults included.
+func (p NotificationPreferences) Effective() NotificationPreferences {
+	effective := make(NotificationPreferences, len(defaultNotificationPreferences))
+	for t := range defaultNotificationPreferences {
+		effective[t] = p.For(t)
+	}
+	return effective
+}
+
+// Validate rejects unknown notification types.
+func (p NotificationPreferences) Validate() error {
+	for t := range p {
+		if !t.IsValid() {
+			return errors.New("unknown notification type: " + string(t))
+		}
+	}
+	return nil
+}
+
+// Merge returns the preferences with the given changes applied.
+func (p NotificationPreferences) Merge(changes NotificationPreferences) NotificationPreferences {
+	merged := make(NotificationPreferences, len(p)+len(changes))
+	for t, pref := range p {
+		merged[t] = pref
+	}
+	for t, pref := range changes {
+		merged[t] = pref
+	}
+	return merged
+}
+
+// Value stores the preferences as a JSON object, never NULL.
+func (p NotificationPreferences) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(p)
+	return string(data), err
+}
+
+func (p *NotificationPreferences) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for notification preferences")
+	}
+	return json.Unmarshal(data, p)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+	stored := NotificationPreferences{
+		NotificationTypeMessage: {InApp: true, Email: true},
+	}
+
+	if got := stored.For(NotificationTypeMessage); got != (ChannelPreference{InApp: true, Email: true}) {
+		t.Errorf("For(message) = %+v, want the stored choice", got)
+	}
+	if got := stored.For(NotificationTypePriceDrop); got != defaultNotificationPreferences[NotificationTypePriceDrop] {
+		t.Errorf("For(price_drop) = %+v, want the default", got)
+	}
+	if got := stored.Effective(); len(got) != len(defaultNotificationPreferences) {
+		t.Errorf("Effective() has %d types, want %d", len(got), len(defaultNotificationPreferences))
+	}
+
+	merged := stored.Merge(NotificationPreferences{
+		NotificationTypePriceDrop: {InApp: false, Email: false},
+	})
+	want := NotificationPreferences{
+		NotificationTypeMessage:   {InApp: true, Email: true},
+		NotificationTypePriceDrop: {InApp: false, Email: false},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("Merge() = %+v, want %+v", merged, want)
+	}
+	if len(stored) != 1 {
+		t.Errorf("Merge() changed the receiver to %+v", stored)
+	}
+}
+
+func TestNotificationPreferencesValidate(t *testing.T) {
+	tests := []struct {
+		prefs   NotificationPreferences
+		wantErr bool
+	}{
+		{nil, false},
+		{NotificationPreferences{NotificationTypeSavedSearch: {}}, false},
+		{NotificationPreferences{"carrier_pigeon": {}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.prefs.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) = %v, want error: %v", tt.prefs, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNotificationPreferencesStorage(t *testing.T) {
+	tests := []struct {
+		prefs NotificationPreferences
+		want  string
+	}{
+		{nil, "{}"},
+		{NotificationPreferences{NotificationTypeMessage: {InApp: true}}, `{"message":{"inApp":true,"email":false}}`},
+	}
+	for _, tt := range tests {
+		value, err := tt.prefs.Value()
+		if err != nil || value != tt.want {
+			t.Errorf("Value(%+v) = %v, %v, want %s", tt.prefs, value, err, tt.want)
+			continue
+		}
+
+		var scanned NotificationPreferences
+		if err := scanned.Scan([]byte(tt.want)); err != nil {
+			t.Fatalf("Scan(%s): %v", tt.want, err)
+		}
+		if len(scanned) != len(tt.prefs) || scanned.For(NotificationTypeMessage) != tt.prefs.For(NotificationTypeMessage) {
+			t.Errorf("Scan(%s) = %+v, want %+v", tt.want, scanned, tt.prefs)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Offer is a buyer's price offer on a negotiable product. The seller can
+// accept, decline or counter it once; the buyer then accepts or declines the
+// counter. Open offers expire at ExpiresAt.
+type Offer struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ProductID      uint        `json:"productId" gorm:"not null;index"`
+	BuyerID        uint        `json:"buyerId" gorm:"not null;index"`
+	SellerID       uint        `json:"sellerId" gorm:"not null;index"`
+	Amount         float64     `json:"amount" gorm:"not null"`
+	CounterAmount  *float64    `json:"counterAmount,omitempty"`
+	AgreedAmount   *float64    `json:"agreedAmount,omitempty"`
+	Message        string      `json:"message,omitempty"`
+	CounterMessage string      `json:"counterMessage,omitempty"`
+	Status         OfferStatus `json:"status" gorm:"default:'pending'"`
+	ExpiresAt      time.Time   `json:"expiresAt" gorm:"not null"`
+	RespondedAt    *time.Time  `json:"respondedAt,omitempty"`
+
+	// Relationships
+	Product Product `json:"product,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Buyer   User    `json:"buyer,omitempty" gorm:"foreignKey:BuyerID"`
+	Seller  User    `json:"seller,omitempty" gorm:"foreignKey:SellerID"`
+}
+
+type OfferStatus string
+
+const (
+	OfferStatusPending   OfferStatus = "pending"
+	OfferStatusCountered OfferStatus = "countered"
+	OfferStatusAccepted  OfferStatus = "accepted"
+	OfferStatusDeclined  OfferStatus = "declined"
+	OfferStatusExpired   OfferStatus = "expired"
+	// An accepted offer is cancelled when the seller releases the reservation
+	OfferStatusCancelled OfferStatus = "cancelled"
+	// The accepted offer of a sale is completed when the sold listing is
+	// removed, so that a restored listing can accept a new offer
+	OfferStatusCompleted OfferStatus = "completed"
+)
+
+// IsOpen reports whether the offer is still awaiting a response.
+func (o *Offer) IsOpen() bool {
+	return o.Status == OfferStatusPending || o.Status == OfferStatusCountered
+}
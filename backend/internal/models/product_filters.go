@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+// MaxSearchRadiusKm bounds location searches.
+const MaxSearchRadiusKm = 500
+
+// ProductFilters is the set of filters accepted by the product listing. Saved
+// searches store it as JSON.
+type ProductFilters struct {
+	Search    string   `json:"search,omitempty"`
+	Category  string   `json:"category,omitempty"`
+	Condition string   `json:"condition,omitempty"`
+	MinPrice  float64  `json:"min_price,omitempty"`
+	MaxPrice  float64  `json:"max_price,omitempty"`
+	Lat       *float64 `json:"lat,omitempty"`
+	Lng       *float64 `json:"lng,omitempty"`
+	RadiusKm  float64  `json:"radius_km,omitempty"`
+}
+
+// HasLocation reports whether the filters include a point to search around.
+func (f ProductFilters) HasLocation() bool {
+	return f.Lat != nil && f.Lng != nil
+}
+
+// IsEmpty reports whether no filter is set.
+func (f ProductFilters) IsEmpty() bool {
+	return f == ProductFilters{}
+}
+
+// Validate checks the filters. The messages are safe to show to the client.
+func (f ProductFilters) Validate() error {
+	if len(f.Search) > 200 {
+		return errors.New("search must be at most 200 characters")
+	}
+	if f.MinPrice < 0 || f.MaxPrice < 0 {
+		return errors.New("prices cannot be negative")
+	}
+	if f.MaxPrice > 0 && f.MinPrice > f.MaxPrice {
+		return errors.New("min_price cannot be greater than max_price")
+	}
+
+	if (f.Lat == nil) != (f.Lng == nil) {
+		return errors.New("lat and lng must be given together")
+	}
+	if f.HasLocation() {
+		if *f.Lat < -90 || *f.Lat > 90 {
+			return errors.New("lat must be a number between -90 and 90")
+		}
+		if *f.Lng < -180 || *f.Lng > 180 {
+			return errors.New("lng must be a number between -180 and 180")
+		}
+	}
+	if f.RadiusKm != 0 {
+		if !f.HasLocation() {
+			return errors.New("radius_km requires lat and lng")
+		}
+		if f.RadiusKm < 0 || f.RadiusKm > MaxSearchRadiusKm {
+			return errors.New("radius_km must be greater than 0 and at most " + strconv.Itoa(MaxSearchRadiusKm))
+		}
+	}
+	return nil
+}
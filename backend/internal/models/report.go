@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Report is a user's complaint about a listing. Open reports make up the
+// moderation queue until a moderator acts on the listing or dismisses them.
+type Report struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ProductID    uint         `json:"productId" gorm:"not null;uniqueIndex:idx_reports_product_reporter"`
+	ReporterID   uint         `json:"reporterId" gorm:"not null;uniqueIndex:idx_reports_product_reporter"`
+	Reason       ReportReason `json:"reason" gorm:"not null"`
+	Details      string       `json:"details,omitempty" gorm:"type:text"`
+	Status       ReportStatus `json:"status" gorm:"default:'open'"`
+	ResolvedByID *uint        `json:"resolvedById,omitempty"`
+	ResolvedAt   *time.Time   `json:"resolvedAt,omitempty"`
+	Resolution   string       `json:"resolution,omitempty"`
+
+	// Relationships
+	Product  Product `json:"product,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Reporter User    `json:"-" gorm:"foreignKey:ReporterID;constraint:OnDelete:CASCADE;"`
+}
+
+// ReportReason is why a listing was reported.
+type ReportReason string
+
+const (
+	ReportReasonSpam          ReportReason = "spam"
+	ReportReasonProhibited    ReportReason = "prohibited"
+	ReportReasonFraud         ReportReason = "fraud"
+	ReportReasonOffensive     ReportReason = "offensive"
+	ReportReasonCounterfeit   ReportReason = "counterfeit"
+	ReportReasonWrongCategory ReportReason = "wrong_category"
+	ReportReasonDuplicate     ReportReason = "duplicate"
+	// ReportReasonOther requires details
+	ReportReasonOther ReportReason = "other"
+)
+
+// ReportReasons lists the reasons users can pick from.
+var ReportReasons = []ReportReason{
+	ReportReasonSpam, ReportReasonProhibited, ReportReasonFraud, ReportReasonOffensive,
+	ReportReasonCounterfeit, ReportReasonWrongCategory, ReportReasonDuplicate, ReportReasonOther,
+}
+
+// IsValid reports whether the reason is known.
+func (r ReportReason) IsValid() bool {
+	for _, reason := range ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen ReportStatus = "open"
+	// ReportStatusActioned reports led a moderator to hide or remove the
+	// listing
+	ReportStatusActioned  ReportStatus = "actioned"
+	ReportStatusDismissed ReportStatus = "dismissed"
+)
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Review is one party's rating of the other after a completed transaction.
+// Each party can review a transaction once, and the reviewed user can reply.
+type Review struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	TransactionID uint       `json:"transactionId" gorm:"not null;uniqueIndex:idx_reviews_transaction_reviewer"`
+	ReviewerID    uint       `json:"reviewerId" gorm:"not null;uniqueIndex:idx_reviews_transaction_reviewer"`
+	RevieweeID    uint       `json:"revieweeId" gorm:"not null;index"`
+	ReviewerRole  string     `json:"reviewerRole" gorm:"not null"` // buyer or seller
+	Rating        int        `json:"rating" gorm:"not null"`
+	Body          string     `json:"body" gorm:"type:text;not null"`
+	Reply         string     `json:"reply,omitempty"`
+	RepliedAt     *time.Time `json:"repliedAt,omitempty"`
+
+	// Public listings only
+	ProductID    uint   `json:"productId,omitempty" gorm:"->;-:migration"`
+	ProductTitle string `json:"productTitle,omitempty" gorm:"->;-:migration"`
+
+	// Relationships
+	Transaction Transaction `json:"transaction,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Reviewer    User        `json:"reviewer,omitempty" gorm:"foreignKey:ReviewerID"`
+	Reviewee    User        `json:"reviewee,omitempty" gorm:"foreignKey:RevieweeID"`
+}
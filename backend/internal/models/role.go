@@ -0,0 +1,81 @@
+package models
+
+// UserRole is what a user may do beyond using the marketplace. Each role
+// grants a fixed set of permissions; handlers and middleware check
+// permissions, never role names.
+type UserRole string
+
+const (
+	UserRoleUser UserRole = "user"
+	// UserRoleSupport helps users and can look up their accounts
+	UserRoleSupport UserRole = "support"
+	// UserRoleModerator works the moderation queue
+	UserRoleModerator UserRole = "moderator"
+	UserRoleAdmin     UserRole = "admin"
+)
+
+// Permission names one privileged capability.
+type Permission string
+
+const (
+	// PermissionDashboardView allows reading the admin dashboard
+	PermissionDashboardView Permission = "dashboard.view"
+	// PermissionUsersView allows listing users and reading their details
+	PermissionUsersView Permission = "users.view"
+	// PermissionUsersSuspend allows suspending and reactivating users
+	PermissionUsersSuspend Permission = "users.suspend"
+	// PermissionUsersRoles allows changing users' roles
+	PermissionUsersRoles Permission = "users.roles"
+	// PermissionProductsView allows listing products in any status and
+	// viewing hidden ones
+	PermissionProductsView Permission = "products.view"
+	// PermissionProductsModerate allows handling reports and approving,
+	// taking down and removing listings
+	PermissionProductsModerate Permission = "products.moderate"
+	// PermissionProductsManage allows deleting and restoring any product
+	PermissionProductsManage Permission = "products.manage"
+	// PermissionAuditView allows reading and verifying the audit log
+	PermissionAuditView Permission = "audit.view"
+)
+
+// rolePermissions maps each role to what it grants.
+var rolePermissions = map[UserRole][]Permission{
+	UserRoleUser: {},
+	UserRoleSupport: {
+		PermissionDashboardView, PermissionUsersView, PermissionProductsView,
+	},
+	UserRoleModerator: {
+		PermissionDashboardView, PermissionUsersView, PermissionProductsView,
+		PermissionProductsModerate,
+	},
+	UserRoleAdmin: {
+		PermissionDashboardView, PermissionUsersView, PermissionUsersSuspend, PermissionUsersRoles,
+		PermissionProductsView, PermissionProductsModerate, PermissionProductsManage,
+		PermissionAuditView,
+	},
+}
+
+// IsValid reports whether the role is known.
+func (r UserRole) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permissions returns what the role grants.
+func (r UserRole) Permissions() []Permission {
+	permissions := rolePermissions[r]
+	if permissions == nil {
+		return []Permission{}
+	}
+	return permissions
+}
+
+// Can reports whether the role grants the permission.
+func (r UserRole) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
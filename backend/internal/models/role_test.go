@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestUserRoleCan(t *testing.T) {
+	tests := []struct {
+		role       UserRole
+		permission Permission
+		want       bool
+	}{
+		{UserRoleAdmin, PermissionUsersRoles, true},
+		{UserRoleAdmin, PermissionAuditView, true},
+		{UserRoleModerator, PermissionProductsModerate, true},
+		{UserRoleModerator, PermissionUsersSuspend, false},
+		{UserRoleModerator, PermissionProductsManage, false},
+		{UserRoleSupport, PermissionUsersView, true},
+		{UserRoleSupport, PermissionProductsModerate, false},
+		{UserRoleUser, PermissionDashboardView, false},
+		{"", PermissionDashboardView, false},
+		{"superuser", PermissionUsersRoles, false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.permission); got != tt.want {
+			t.Errorf("%q.Can(%s) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestUserRoleIsValid(t *testing.T) {
+	tests := []struct {
+		role UserRole
+		want bool
+	}{
+		{UserRoleUser, true},
+		{UserRoleSupport, true},
+		{UserRoleModerator, true},
+		{UserRoleAdmin, true},
+		{"", false},
+		{"Admin", false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.IsValid(); got != tt.want {
+			t.Errorf("%q.IsValid() = %v, want %v", tt.role, got, tt.want)
+		}
+		if permissions := tt.role.Permissions(); permissions == nil {
+			t.Errorf("%q.Permissions() = nil, want a list that encodes as []", tt.role)
+		}
+	}
+}
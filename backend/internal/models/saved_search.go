@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// SavedSearch is a product search a user saved to be alerted about new
+// matching listings.
+type SavedSearch struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID         uint           `json:"userId" gorm:"not null;index"`
+	Name           string         `json:"name" gorm:"not null"`
+	Filters        ProductFilters `json:"filters" gorm:"type:jsonb;serializer:json"`
+	AlertMode      AlertMode      `json:"alertMode" gorm:"default:'instant'"`
+	LastMatchedAt  time.Time      `json:"lastMatchedAt"`
+	LastMatchedID  uint           `json:"-"` // breaks ties in LastMatchedAt
+	LastNotifiedAt *time.Time     `json:"lastNotifiedAt,omitempty"`
+
+	// Relationships
+	User User `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// AlertMode is how a saved search notifies its owner about new matches.
+type AlertMode string
+
+const (
+	// AlertModeInstant notifies as soon as the matcher finds new listings
+	AlertModeInstant AlertMode = "instant"
+	// AlertModeDaily collects matches into a digest at most once a day
+	AlertModeDaily AlertMode = "daily"
+)
+
+// IsValid reports whether the mode is known.
+func (m AlertMode) IsValid() bool {
+	return m == AlertModeInstant || m == AlertModeDaily
+}
+
+// SavedSearchMatch is a listing the matcher found for a saved search.
+type SavedSearchMatch struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	SavedSearchID uint       `json:"savedSearchId" gorm:"not null;uniqueIndex:idx_saved_search_matches_search_product"`
+	ProductID     uint       `json:"productId" gorm:"not null;uniqueIndex:idx_saved_search_matches_search_product"`
+	NotifiedAt    *time.Time `json:"notifiedAt,omitempty"`
+
+	// Relationships
+	SavedSearch SavedSearch `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Product     Product     `json:"product,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+}
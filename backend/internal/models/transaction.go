@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Transaction records the sale of a product to a buyer. It is created when
+// the seller marks the product sold to someone and completed when the buyer
+// confirms receipt.
+type Transaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ProductID   uint              `json:"productId" gorm:"not null;index"`
+	SellerID    uint              `json:"sellerId" gorm:"not null;index"`
+	BuyerID     uint              `json:"buyerId" gorm:"not null;index"`
+	OfferID     *uint             `json:"offerId,omitempty"`
+	Price       float64           `json:"price" gorm:"not null"`
+	Status      TransactionStatus `json:"status" gorm:"default:'pending'"`
+	SoldAt      time.Time         `json:"soldAt" gorm:"not null"`
+	ConfirmedAt *time.Time        `json:"confirmedAt,omitempty"`
+
+	// Relationships
+	Product Product `json:"product,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Seller  User    `json:"seller,omitempty" gorm:"foreignKey:SellerID"`
+	Buyer   User    `json:"buyer,omitempty" gorm:"foreignKey:BuyerID"`
+}
+
+type TransactionStatus string
+
+const (
+	// TransactionStatusPending is awaiting the buyer's confirmation
+	TransactionStatusPending   TransactionStatus = "pending"
+	TransactionStatusCompleted TransactionStatus = "completed"
+)
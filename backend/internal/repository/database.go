@@ -3,6 +3,7 @@ package repository
 import (
 	"bech-do-backend/internal/config"
 	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/geo"
 	"log"
 	"time"
 
@@ -31,6 +32,8 @@ func InitDatabase() *gorm.DB {
 	// Connect to database with Supabase-optimized settings
 	db, err := gorm.Open(postgres.Open(config.DatabaseURL), &gorm.Config{
 		Logger: gormLogger,
+		// Report unique violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -63,6 +66,9 @@ func InitDatabase() *gorm.DB {
 	// Seed default data (will skip if already exists)
 	seedDefaultData(db)
 
+	// Geocode listings that have a pincode but no coordinates yet
+	backfillProductCoordinates(db)
+
 	DB = db
 	return db
 }
@@ -114,3 +120,28 @@ func seedDefaultData(db *gorm.DB) {
 		log.Println("Created demo user: admin@demo.com / password123")
 	}
 }
+
+func backfillProductCoordinates(db *gorm.DB) {
+	var products []models.Product
+	result := db.Select("id", "pin_code").
+		Where("latitude IS NULL AND pin_code IS NOT NULL AND pin_code <> ''").
+		Find(&products)
+	if result.Error != nil {
+		log.Printf("Failed to load products for geocoding: %v", result.Error)
+		return
+	}
+
+	updated := 0
+	for _, product := range products {
+		point, ok := geo.LookupPinCode(product.PinCode)
+		if !ok {
+			continue
+		}
+		db.Model(&models.Product{}).Where("id = ?", product.ID).
+			UpdateColumns(map[string]interface{}{"latitude": point.Lat, "longitude": point.Lng})
+		updated++
+	}
+	if updated > 0 {
+		log.Printf("Geocoded %d products from their pincodes", updated)
+	}
+}
@@ -0,0 +1,177 @@
+// Package audit writes and checks the audit log. Entries are chained: each
+// one stores the hash of its predecessor and a hash over its own content and
+// that predecessor hash, so editing, deleting or reordering entries breaks
+// the chain at the first tampered entry.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"bech-do-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// chainLock is the advisory lock key that serializes appends to the chain.
+const chainLock = 0x61756469
+
+// verifyBatchSize is how many entries Verify loads at a time.
+const verifyBatchSize = 500
+
+// ErrBrokenChain is returned by Verify when an entry does not match its hash
+// or does not follow the entry before it.
+var ErrBrokenChain = errors.New("audit log chain is broken")
+
+// Record appends the entry to the log, filling in its time and hashes. Pass
+// the transaction that makes the audited change so that the entry is written
+// if and only if the change is.
+func Record(db *gorm.DB, entry *models.AuditEntry) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Appends must see the latest entry, so they take turns
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLock).Error; err != nil {
+			return err
+		}
+
+		var prevHash string
+		err := tx.Model(&models.AuditEntry{}).Order("id DESC").Limit(1).Pluck("hash", &prevHash).Error
+		if err != nil {
+			return err
+		}
+
+		// Postgres keeps microseconds; hash what will be read back
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		entry.PrevHash = prevHash
+		hash, err := Hash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+
+		return tx.Create(entry).Error
+	})
+}
+
+// Hash computes the hash of the entry's content and its PrevHash.
+func Hash(entry *models.AuditEntry) (string, error) {
+	changes, err := canonicalChanges(entry.Changes)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := json.Marshal(struct {
+		PrevHash   string          `json:"prevHash"`
+		CreatedAt  string          `json:"createdAt"`
+		ActorID    *uint           `json:"actorId"`
+		ActorEmail string          `json:"actorEmail"`
+		Action     string          `json:"action"`
+		TargetType string          `json:"targetType"`
+		TargetID   *uint           `json:"targetId"`
+		Changes    json.RawMessage `json:"changes"`
+		IP         string          `json:"ip"`
+		UserAgent  string          `json:"userAgent"`
+	}{
+		PrevHash:   entry.PrevHash,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ActorID:    entry.ActorID,
+		ActorEmail: entry.ActorEmail,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Changes:    changes,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalChanges encodes changes the same way whether they were built in
+// Go or read back from jsonb, by passing them through generic JSON values.
+// Object keys come out sorted.
+func canonicalChanges(changes map[string]interface{}) (json.RawMessage, error) {
+	if len(changes) == 0 {
+		return json.RawMessage("null"), nil
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// Verify walks the whole log in order and checks every entry's hash and
+// link to its predecessor. It returns the number of entries checked and,
+// when the chain is broken, the ID of the first bad entry with
+// ErrBrokenChain.
+func Verify(db *gorm.DB) (checked int, badID uint, err error) {
+	prevHash := ""
+	lastID := uint(0)
+
+	for {
+		var entries []models.AuditEntry
+		err := db.Where("id > ?", lastID).Order("id ASC").Limit(verifyBatchSize).Find(&entries).Error
+		if err != nil {
+			return checked, 0, err
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if err := checkLink(entry, prevHash); err != nil {
+				return checked, entry.ID, err
+			}
+			prevHash = entry.Hash
+			lastID = entry.ID
+			checked++
+		}
+
+		if len(entries) < verifyBatchSize {
+			return checked, 0, nil
+		}
+	}
+}
+
+// checkLink checks the entry's hash and that it follows the entry whose
+// hash is prevHash.
+func checkLink(entry *models.AuditEntry, prevHash string) error {
+	hash, err := Hash(entry)
+	if err != nil {
+		return fmt.Errorf("%w: entry %d: %v", ErrBrokenChain, entry.ID, err)
+	}
+	if entry.PrevHash != prevHash || entry.Hash != hash {
+		return fmt.Errorf("%w at entry %d", ErrBrokenChain, entry.ID)
+	}
+	return nil
+}
+
+// Diff returns the fields whose values differ between before and after, as
+// {"field": {"before": ..., "after": ...}}. Fields missing from one side are
+// reported with a nil value on that side.
+func Diff(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for field, old := range before {
+		if updated, ok := after[field]; !ok || !reflect.DeepEqual(old, updated) {
+			changes[field] = map[string]interface{}{"before": old, "after": updated}
+		}
+	}
+	for field, updated := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = map[string]interface{}{"before": nil, "after": updated}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"bech-do-backend/internal/models"
+)
+
+// chain builds n linked entries the way Record does.
+func chain(t *testing.T, n int) []models.AuditEntry {
+	t.Helper()
+	start := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	entries := make([]models.AuditEntry, n)
+	prevHash := ""
+	for i := range entries {
+		actorID := uint(1)
+		targetID := uint(100 + i)
+		entry := &entries[i]
+		entry.ID = uint(i + 1)
+		entry.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		entry.ActorID = &actorID
+		entry.ActorEmail = "admin@example.com"
+		entry.Action = models.AuditUserSuspend
+		entry.TargetType = models.AuditTargetUser
+		entry.TargetID = &targetID
+		entry.Changes = map[string]interface{}{"isActive": map[string]interface{}{"before": true, "after": false}}
+		entry.IP = "203.0.113.7"
+		entry.PrevHash = prevHash
+
+		hash, err := Hash(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Hash = hash
+		prevHash = hash
+	}
+	return entries
+}
+
+// verify checks entries as Verify does and returns the ID of the first bad
+// entry, or 0.
+func verify(entries []models.AuditEntry) uint {
+	prevHash := ""
+	for i := range entries {
+		if err := checkLink(&entries[i], prevHash); err != nil {
+			if !errors.Is(err, ErrBrokenChain) {
+				panic(err)
+			}
+			return entries[i].ID
+		}
+		prevHash = entries[i].Hash
+	}
+	return 0
+}
+
+func TestChainVerification(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry
+		badID  uint
+	}{
+		{
+			name:   "intact",
+			tamper: func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry { return entries },
+		},
+		{
+			name: "edited action",
+			tamper: func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry {
+				entries[2].Action = models.AuditUserReactivate
+				return entries
+			},
+			badID: 3,
+		},
+		{
+			name: "edited changes",
+			tamper: func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry {
+				entries[1].Changes = map[string]interface{}{"isActive": map[string]interface{}{"before": true, "after": true}}
+				return entries
+			},
+			badID: 2,
+		},
+		{
+			name: "edited time",
+			tamper: func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry {
+				entries[0].CreatedAt = entries[0].CreatedAt.Add(time.Second)
+				return entries
+			},
+			badID: 1,
+		},
+		{
+			name: "deleted entry",
+			tamper: func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry {
+				return append(entries[:2], entries[3:]...)
+			},
+			badID: 4,
+		},
+		{
+			name: "reordered entries",
+			tamper: func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry {
+				entries[1], entries[2] = entries[2], entries[1]
+				return entries
+			},
+			badID: 3,
+		},
+		{
+			name: "edited and rehashed",
+			tamper: func(t *testing.T, entries []models.AuditEntry) []models.AuditEntry {
+				entries[1].IP = "198.51.100.1"
+				hash, err := Hash(&entries[1])
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries[1].Hash = hash
+				return entries
+			},
+			badID: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(t, chain(t, 5))
+			if got := verify(entries); got != tt.badID {
+				t.Errorf("first bad entry = %d, want %d", got, tt.badID)
+			}
+		})
+	}
+}
+
+func TestHashMatchesChangesReadBack(t *testing.T) {
+	// Changes built in Go are read back from jsonb as generic JSON values
+	built := chain(t, 1)[0]
+	built.Changes = map[string]interface{}{"price": map[string]interface{}{"before": 100.0, "after": uint(80)}, "count": 3}
+	readBack := built
+	readBack.Changes = map[string]interface{}{"count": float64(3), "price": map[string]interface{}{"after": float64(80), "before": float64(100)}}
+
+	builtHash, err := Hash(&built)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readBackHash, err := Hash(&readBack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if builtHash != readBackHash {
+		t.Errorf("hash of changes read back = %s, want %s", readBackHash, builtHash)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after map[string]interface{}
+		want          map[string]interface{}
+	}{
+		{
+			name:   "unchanged",
+			before: map[string]interface{}{"price": 100.0, "title": "Desk"},
+			after:  map[string]interface{}{"price": 100.0, "title": "Desk"},
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "changed",
+			before: map[string]interface{}{"price": 100.0, "title": "Desk"},
+			after:  map[string]interface{}{"price": 80.0, "title": "Desk"},
+			want:   map[string]interface{}{"price": map[string]interface{}{"before": 100.0, "after": 80.0}},
+		},
+		{
+			name:   "added and removed",
+			before: map[string]interface{}{"reason": "spam"},
+			after:  map[string]interface{}{"note": "ok"},
+			want: map[string]interface{}{
+				"reason": map[string]interface{}{"before": "spam", "after": nil},
+				"note":   map[string]interface{}{"before": nil, "after": "ok"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Package events is an in-process bus for domain events. Producers emit
+// events after their changes are committed; consumers such as realtime
+// delivery and notifications subscribe to them.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	// A message was sent; addressed to both participants
+	MessageCreated = "message.created"
+	// A buyer made an offer; addressed to the seller
+	OfferCreated = "offer.created"
+	// A product was sold to a known buyer; addressed to the buyer
+	TransactionCreated = "transaction.created"
+	// A listing will expire soon; addressed to the seller
+	ListingExpiring = "listing.expiring"
+	// A moderator acted on the recipient's listing
+	ModerationAction = "moderation.action"
+	// A product the recipients favorited changed price
+	FavoritePriceChanged = "favorite.price_changed"
+	// A product the recipients favorited was sold
+	FavoriteSold = "favorite.sold"
+	// New listings matched a saved search with instant alerts
+	SavedSearchMatched = "saved_search.matched"
+	// The daily digest of a saved search's new matches
+	SavedSearchDigest = "saved_search.digest"
+)
+
+// Event is something that happened, addressed to the users it concerns.
+type Event struct {
+	Type    string      `json:"type"`
+	UserIDs []uint      `json:"-"`
+	Data    interface{} `json:"data"`
+	At      time.Time   `json:"at"`
+}
+
+type Handler func(ctx context.Context, event Event)
+
+var (
+	mu       sync.RWMutex
+	handlers []Handler
+)
+
+// Subscribe registers a handler for every event. Handlers filter by type.
+func Subscribe(handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, handler)
+}
+
+// Emit delivers an event to all subscribers, synchronously and in order of
+// subscription. A panicking handler is logged and does not affect the others.
+func Emit(ctx context.Context, eventType string, data interface{}, userIDs ...uint) {
+	event := Event{Type: eventType, UserIDs: userIDs, Data: data, At: time.Now().UTC()}
+
+	mu.RLock()
+	subscribers := handlers
+	mu.RUnlock()
+
+	for _, handler := range subscribers {
+		dispatch(ctx, handler, event)
+	}
+}
+
+func dispatch(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler for %s panicked: %v", event.Type, r)
+		}
+	}()
+	handler(ctx, event)
+}
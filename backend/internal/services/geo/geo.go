@@ -0,0 +1,104 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed pincodes.csv
+var pincodeData string
+
+const earthRadiusKm = 6371.0
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+var (
+	loadOnce  sync.Once
+	pincodes  map[string]Point
+	districts map[string]Point
+)
+
+func load() {
+	pincodes = make(map[string]Point)
+	districts = make(map[string]Point)
+
+	reader := csv.NewReader(strings.NewReader(pincodeData))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		log.Printf("Failed to parse pincode dataset: %v", err)
+		return
+	}
+
+	for _, record := range records {
+		if len(record) < 3 {
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(record[1], 64)
+		lng, lngErr := strconv.ParseFloat(record[2], 64)
+		if latErr != nil || lngErr != nil {
+			continue
+		}
+
+		code := strings.TrimSpace(record[0])
+		switch len(code) {
+		case 6:
+			pincodes[code] = Point{Lat: lat, Lng: lng}
+		case 3:
+			districts[code] = Point{Lat: lat, Lng: lng}
+		}
+	}
+}
+
+// LookupPinCode returns the approximate coordinates of an Indian pincode.
+// Exact pincodes are preferred; otherwise the centroid of the pincode's
+// sorting district (its first three digits) is used.
+func LookupPinCode(pinCode string) (Point, bool) {
+	loadOnce.Do(load)
+
+	pinCode = strings.ReplaceAll(strings.TrimSpace(pinCode), " ", "")
+	if len(pinCode) != 6 {
+		return Point{}, false
+	}
+	if _, err := strconv.Atoi(pinCode); err != nil {
+		return Point{}, false
+	}
+
+	if point, ok := pincodes[pinCode]; ok {
+		return point, true
+	}
+	point, ok := districts[pinCode[:3]]
+	return point, ok
+}
+
+// Round coarsens a point to the given number of decimal places. Two decimal
+// places is roughly a 1 km grid.
+func Round(p Point, decimals int) Point {
+	factor := math.Pow(10, float64(decimals))
+	return Point{
+		Lat: math.Round(p.Lat*factor) / factor,
+		Lng: math.Round(p.Lng*factor) / factor,
+	}
+}
+
+// BoundingBox returns the latitude and longitude ranges that contain every
+// point within radiusKm of center. It is used to narrow queries before the
+// exact distance is computed.
+func BoundingBox(center Point, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	latDelta := radiusKm / earthRadiusKm * 180 / math.Pi
+	lngDelta := 180.0
+	if cos := math.Cos(center.Lat * math.Pi / 180); cos > 1e-6 {
+		lngDelta = math.Min(180, latDelta/cos)
+	}
+	return center.Lat - latDelta, center.Lat + latDelta, center.Lng - lngDelta, center.Lng + lngDelta
+}
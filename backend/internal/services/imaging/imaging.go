@@ -0,0 +1,130 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+// Variant describes a resized rendition. Cropped variants are filled to the
+// exact size; the others are scaled to fit inside the box. Images are never
+// upscaled.
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// Variants are generated for every uploaded image, smallest first.
+var Variants = []Variant{
+	{Name: "thumb", Width: 200, Height: 200, Crop: true},
+	{Name: "card", Width: 600, Height: 600},
+	{Name: "full", Width: 1600, Height: 1600},
+}
+
+// Rendition is an encoded JPEG produced by Process.
+type Rendition struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// Normalize decodes an image, rotates it upright according to its EXIF
+// orientation and re-encodes it as a full-size JPEG named "original".
+// Re-encoding drops all metadata, including EXIF GPS coordinates, so only
+// the normalized image should ever be stored.
+func Normalize(data []byte) (Rendition, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Rendition{}, err
+	}
+	return encode("original", upright(img, exifOrientation(data)))
+}
+
+// Resize generates every entry of Variants from a normalized image. It is
+// slower than Normalize and runs in the background.
+func Resize(original []byte) ([]Rendition, error) {
+	img, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return nil, err
+	}
+
+	renditions := make([]Rendition, 0, len(Variants))
+	for _, variant := range Variants {
+		rendition, err := encode(variant.Name, resize(img, variant))
+		if err != nil {
+			return nil, err
+		}
+		renditions = append(renditions, rendition)
+	}
+	return renditions, nil
+}
+
+func encode(name string, img image.Image) (Rendition, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return Rendition{}, err
+	}
+	return Rendition{
+		Name:   name,
+		Data:   buf.Bytes(),
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}, nil
+}
+
+// flatten draws the image onto a white background, since JPEG has no alpha.
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+func resize(img image.Image, variant Variant) image.Image {
+	src := img.Bounds()
+	w, h := src.Dx(), src.Dy()
+
+	if variant.Crop {
+		// Crop the centre of the image to the variant's aspect ratio
+		cropW, cropH := w, w*variant.Height/variant.Width
+		if cropH > h {
+			cropW, cropH = h*variant.Width/variant.Height, h
+		}
+		x0 := src.Min.X + (w-cropW)/2
+		y0 := src.Min.Y + (h-cropH)/2
+		src = image.Rect(x0, y0, x0+cropW, y0+cropH)
+		w, h = cropW, cropH
+
+		dstW, dstH := variant.Width, variant.Height
+		if w < dstW {
+			dstW, dstH = w, h
+		}
+		return scale(img, src, dstW, dstH)
+	}
+
+	if w <= variant.Width && h <= variant.Height {
+		return img
+	}
+	dstW, dstH := variant.Width, h*variant.Width/w
+	if dstH > variant.Height {
+		dstW, dstH = w*variant.Height/h, variant.Height
+	}
+	return scale(img, src, max(dstW, 1), max(dstH, 1))
+}
+
+func scale(img image.Image, src image.Rectangle, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,126 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const exifOrientationTag = 0x0112
+
+// exifOrientation returns the EXIF orientation (1-8) of a JPEG, or 1 when the
+// image has none.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		// Start of scan: no more metadata segments follow
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value < 1 || value > 8 {
+				return 1
+			}
+			return value
+		}
+	}
+	return 1
+}
+
+// upright flattens img onto a white background, like flatten, and turns it
+// upright for the given EXIF orientation. Rows are flattened one at a time
+// straight into their place in the result, so a large upload is copied once
+// rather than once to rotate it and again to flatten it.
+func upright(img image.Image, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return flatten(img)
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+
+	row := image.NewRGBA(image.Rect(0, 0, w, 1))
+	white := image.NewUniform(color.White)
+	for sy := 0; sy < h; sy++ {
+		draw.Draw(row, row.Bounds(), white, image.Point{}, draw.Src)
+		draw.Draw(row, row.Bounds(), img, image.Pt(b.Min.X, b.Min.Y+sy), draw.Over)
+
+		for sx := 0; sx < w; sx++ {
+			var x, y int
+			switch orientation {
+			case 2: // mirrored horizontally
+				x, y = w-1-sx, sy
+			case 3: // rotated 180
+				x, y = w-1-sx, h-1-sy
+			case 4: // mirrored vertically
+				x, y = sx, h-1-sy
+			case 5: // transposed
+				x, y = sy, sx
+			case 6: // needs 90 degrees clockwise
+				x, y = h-1-sy, sx
+			case 7: // transversed
+				x, y = h-1-sy, w-1-sx
+			case 8: // needs 90 degrees counter-clockwise
+				x, y = sy, w-1-sx
+			}
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], row.Pix[sx*4:sx*4+4])
+		}
+	}
+	return dst
+}
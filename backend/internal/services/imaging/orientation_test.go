@@ -0,0 +1,99 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestUpright(t *testing.T) {
+	// A 3×2 image with a distinct colour per pixel, one of them transparent
+	src := image.NewNRGBA(image.Rect(10, 20, 13, 22))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			src.SetNRGBA(10+x, 20+y, color.NRGBA{R: uint8(x * 80), G: uint8(y * 80), B: 40, A: 255})
+		}
+	}
+	src.SetNRGBA(12, 21, color.NRGBA{A: 0})
+	w, h := 3, 2
+
+	// Where each pixel of the upright image comes from, as the EXIF
+	// orientations define it
+	tests := []struct {
+		orientation int
+		source      func(x, y int) (int, int)
+	}{
+		{1, func(x, y int) (int, int) { return x, y }},
+		{2, func(x, y int) (int, int) { return w - 1 - x, y }},
+		{3, func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }},
+		{4, func(x, y int) (int, int) { return x, h - 1 - y }},
+		{5, func(x, y int) (int, int) { return y, x }},
+		{6, func(x, y int) (int, int) { return y, h - 1 - x }},
+		{7, func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }},
+		{8, func(x, y int) (int, int) { return w - 1 - y, x }},
+	}
+	for _, tt := range tests {
+		got := upright(src, tt.orientation)
+
+		wantW, wantH := w, h
+		if tt.orientation >= 5 {
+			wantW, wantH = h, w
+		}
+		if b := got.Bounds(); b != image.Rect(0, 0, wantW, wantH) {
+			t.Errorf("orientation %d: bounds = %v, want %dx%d", tt.orientation, b, wantW, wantH)
+			continue
+		}
+
+		for y := 0; y < wantH; y++ {
+			for x := 0; x < wantW; x++ {
+				sx, sy := tt.source(x, y)
+				want := color.RGBAModel.Convert(src.At(10+sx, 20+sy)).(color.RGBA)
+				if want.A == 0 {
+					want = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+				}
+				if c := got.RGBAAt(x, y); c != want {
+					t.Errorf("orientation %d: pixel (%d, %d) = %v, want %v", tt.orientation, x, y, c, want)
+				}
+			}
+		}
+	}
+}
+
+func TestNormalizeAppliesEXIFOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	plain := buf.Bytes()
+
+	// An APP1 segment with a big-endian TIFF holding only the orientation
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8,
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, 6, 0, 0, // orientation 6, a SHORT
+		0, 0, 0, 0,
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := append([]byte{0xFF, 0xE1, 0, byte(len(payload) + 2)}, payload...)
+	rotated := append(append(append([]byte{}, plain[:2]...), app1...), plain[2:]...)
+
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+	}{
+		{"no orientation", plain, 40, 20},
+		{"rotated 90 degrees", rotated, 20, 40},
+	}
+	for _, tt := range tests {
+		rendition, err := Normalize(tt.data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if rendition.Width != tt.width || rendition.Height != tt.height {
+			t.Errorf("%s: normalized to %dx%d, want %dx%d", tt.name, rendition.Width, rendition.Height, tt.width, tt.height)
+		}
+	}
+}
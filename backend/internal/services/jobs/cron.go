@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed schedule. It accepts the standard five fields (minute,
+// hour, day of month, month, day of week) with *, lists, ranges and steps,
+// the shorthands @hourly, @daily, @weekly and @monthly, and "@every <d>" for
+// fixed intervals aligned to the Unix epoch. Times are evaluated in UTC.
+type Cron struct {
+	every time.Duration
+
+	minutes, hours, days, months, weekdays uint64
+	// Standard cron matches either day field when both are restricted
+	anyDay, anyWeekday bool
+}
+
+var cronShorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseCron parses a schedule spec.
+func ParseCron(spec string) (*Cron, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := cronShorthands[spec]; ok {
+		spec = expanded
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("invalid interval %q", rest)
+		}
+		return &Cron{every: every}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in %q", spec)
+	}
+
+	c := &Cron{}
+	var err error
+	if c.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// Sunday is both 0 and 7
+	if c.weekdays&(1<<7) != 0 {
+		c.weekdays |= 1
+	}
+	c.anyDay = fields[2] == "*"
+	c.anyWeekday = fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField returns the allowed values of one field as a bit set.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = before, n
+		}
+
+		low, high := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", part)
+				}
+			} else if step > 1 {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	if bits == 0 {
+		return 0, errors.New("empty cron field")
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t that matches the schedule.
+func (c *Cron) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Truncate(c.every).Add(c.every)
+	}
+
+	next := t.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Every schedule matches at least once within a few years; the bound
+	// only guards against impossible dates such as February 30
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if c.months&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+			continue
+		}
+		if !c.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+			continue
+		}
+		if c.hours&(1<<uint(next.Hour())) == 0 {
+			next = next.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if c.minutes&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return limit
+}
+
+func (c *Cron) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsInvalidSpecs(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 500ms",
+		"@every soon",
+		"@yearly",
+	}
+	for _, spec := range specs {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2024, time.January, 10, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 10, 10, 31, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2024, time.January, 10, 10, 35, 0, 0, time.UTC)},
+		{"15,45 * * * *", time.Date(2024, time.January, 10, 10, 45, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, time.January, 11, 3, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, time.January, 10, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1", time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		// Sunday is both 0 and 7
+		{"0 0 * * 7", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 0 20 * 5", time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{"@every 15m", time.Date(2024, time.January, 10, 10, 45, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("ParseCron(%q).Next(%v) = %v, want %v", tt.spec, from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextIsAfterMatchingTime(t *testing.T) {
+	c, err := ParseCron("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, time.January, 10, 10, 0, 0, 0, time.UTC)
+	want := at.Add(time.Hour)
+	if got := c.Next(at); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", at, got, want)
+	}
+}
@@ -0,0 +1,107 @@
+// Package jobs runs background work stored in the jobs table. Any number of
+// runners, in the server or in the worker binary, can share the table:
+// jobs are claimed with SELECT ... FOR UPDATE SKIP LOCKED, so each runs once
+// at a time. Failed jobs are retried with exponential backoff and end up dead
+// after their last attempt.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"bech-do-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Job kinds
+const (
+	// Send one email; the payload is a mailer.Message
+	KindSendEmail = "email.send"
+	// Notify the recipients of a domain event
+	KindDeliverNotification = "notifications.deliver"
+	// Issue a password reset token and email its link
+	KindSendPasswordReset = "email.password_reset"
+	// Email a link to verify the user's address
+	KindSendVerification = "email.verification"
+	// Generate the resized variants of an upload
+	KindImageVariants = "images.variants"
+	// Match new listings against saved searches
+	KindMatchSavedSearches = "saved_searches.match"
+	// Expire offers that were not answered in time
+	KindExpireOffers = "offers.expire"
+	// Warn about and expire listings at the end of their listing period
+	KindExpireListings = "products.expire"
+	// Add buffered product view counts
+	KindRecordViews = "products.views"
+	// Delete finished jobs past the retention period
+	KindCleanupJobs = "jobs.cleanup"
+	// Delete stale failed sign-in counters
+	KindCleanupLoginThrottles = "login_throttles.cleanup"
+)
+
+const defaultMaxAttempts = 5
+
+// Options control how a job is enqueued.
+type Options struct {
+	// RunAt delays the job; the zero value runs it as soon as possible
+	RunAt time.Time
+	// MaxAttempts defaults to 5
+	MaxAttempts int
+	// Key makes the job unique: enqueueing a second job with the same key
+	// does nothing
+	Key string
+}
+
+// Enqueue stores a job for the runners to pick up. Pass a transaction as db
+// to enqueue the job only if the transaction commits.
+func Enqueue(db *gorm.DB, kind string, payload interface{}, opts Options) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	job := models.Job{
+		Kind:        kind,
+		Payload:     string(data),
+		Status:      models.JobStatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = defaultMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if opts.Key != "" {
+		job.UniqueKey = &opts.Key
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&job).Error
+}
+
+// Handler runs one job. Returning an error schedules a retry.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Permanent wraps an error to fail a job without further retries, e.g. when
+// its payload is invalid.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// Cleanup returns the handler that deletes jobs that finished more than
+// retention ago. Dead jobs are kept until someone looks at them.
+func Cleanup(db *gorm.DB, retention time.Duration) Handler {
+	return func(ctx context.Context, job *models.Job) error {
+		return db.WithContext(ctx).
+			Where("status = ? AND completed_at < ?", models.JobStatusDone, time.Now().Add(-retention)).
+			Delete(&models.Job{}).Error
+	}
+}
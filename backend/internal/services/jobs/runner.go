@@ -0,0 +1,307 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"bech-do-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// backoffBase is the delay before the first retry; it doubles per attempt
+	backoffBase = 10 * time.Second
+	backoffMax  = time.Hour
+	// rescueInterval is how often jobs left running by a crashed runner are
+	// looked for
+	rescueInterval = time.Minute
+)
+
+// Config tunes a Runner.
+type Config struct {
+	// Workers is how many jobs run at the same time
+	Workers int
+	// PollInterval is how long an idle worker waits before looking again
+	PollInterval time.Duration
+	// JobTimeout bounds a single run of a job
+	JobTimeout time.Duration
+	// ShutdownTimeout is how long Run waits for running jobs once its
+	// context is cancelled
+	ShutdownTimeout time.Duration
+}
+
+// Runner claims and runs jobs, and enqueues scheduled jobs when they are due.
+type Runner struct {
+	db        *gorm.DB
+	cfg       Config
+	name      string
+	handlers  map[string]Handler
+	schedules []*schedule
+}
+
+func NewRunner(db *gorm.DB, cfg Config) *Runner {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = 5 * time.Minute
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+
+	hostname, _ := os.Hostname()
+	return &Runner{
+		db:       db,
+		cfg:      cfg,
+		name:     fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register sets the handler for a job kind. Runners only claim the kinds
+// they have handlers for.
+func (r *Runner) Register(kind string, handler Handler) {
+	r.handlers[kind] = handler
+}
+
+// Schedule enqueues a job of the given kind, with an empty payload, every
+// time the cron spec comes due. Every runner may schedule the same job; each
+// slot is enqueued once.
+func (r *Runner) Schedule(spec, kind string) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", kind, err)
+	}
+	r.schedules = append(r.schedules, &schedule{cron: cron, kind: kind})
+	return nil
+}
+
+// Run works until ctx is cancelled, then waits up to ShutdownTimeout for the
+// running jobs to finish. Jobs still running after that are picked up again
+// once their lock goes stale.
+func (r *Runner) Run(ctx context.Context) error {
+	if len(r.handlers) == 0 {
+		return errors.New("jobs: no handlers registered")
+	}
+
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	log.Printf("Job runner %s started with %d workers", r.name, r.cfg.Workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx, kinds)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.tick(ctx)
+	}()
+
+	<-ctx.Done()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Printf("Job runner %s stopped", r.name)
+		return nil
+	case <-time.After(r.cfg.ShutdownTimeout):
+		return errors.New("jobs: timed out waiting for running jobs")
+	}
+}
+
+// work runs jobs one after another until ctx is cancelled.
+func (r *Runner) work(ctx context.Context, kinds []string) {
+	for ctx.Err() == nil {
+		job, err := r.claim(kinds)
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(r.cfg.PollInterval):
+			}
+			continue
+		}
+		r.execute(job)
+	}
+}
+
+// claim locks the next due job, or returns nil when there is none.
+func (r *Runner) claim(kinds []string) (*models.Job, error) {
+	var jobs []models.Job
+	err := r.db.Raw(`UPDATE jobs SET status = ?, attempts = attempts + 1, locked_at = NOW(), locked_by = ?, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = ? AND run_at <= NOW() AND kind IN ?
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, models.JobStatusRunning, r.name, models.JobStatusPending, kinds).
+		Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// execute runs a claimed job and records the outcome. The job gets its own
+// context so that shutting down lets it finish.
+func (r *Runner) execute(job *models.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.JobTimeout)
+	defer cancel()
+
+	err := r.safeRun(ctx, job)
+	if err == nil {
+		now := time.Now()
+		r.finish(job, map[string]interface{}{
+			"status":       models.JobStatusDone,
+			"completed_at": now,
+			"last_error":   "",
+		})
+		return
+	}
+
+	var permanent permanentError
+	if job.Attempts >= job.MaxAttempts || errors.As(err, &permanent) {
+		log.Printf("Job %d (%s) failed permanently after %d attempts: %v", job.ID, job.Kind, job.Attempts, err)
+		r.finish(job, map[string]interface{}{
+			"status":     models.JobStatusDead,
+			"last_error": err.Error(),
+		})
+		return
+	}
+
+	delay := backoff(job.Attempts)
+	log.Printf("Job %d (%s) failed, retrying in %s: %v", job.ID, job.Kind, delay.Round(time.Second), err)
+	r.finish(job, map[string]interface{}{
+		"status":     models.JobStatusPending,
+		"run_at":     time.Now().Add(delay),
+		"last_error": err.Error(),
+	})
+}
+
+func (r *Runner) safeRun(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return r.handlers[job.Kind](ctx, job)
+}
+
+// finish releases the job's lock. It only applies while this runner still
+// holds the job, in case the lock went stale and another runner took over.
+func (r *Runner) finish(job *models.Job, updates map[string]interface{}) {
+	updates["locked_at"] = nil
+	updates["locked_by"] = ""
+	err := r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.JobStatusRunning, r.name).
+		Updates(updates).Error
+	if err != nil {
+		log.Printf("Failed to record the result of job %d: %v", job.ID, err)
+	}
+}
+
+// tick enqueues scheduled jobs when they come due and rescues stale jobs.
+func (r *Runner) tick(ctx context.Context) {
+	now := time.Now()
+	for _, s := range r.schedules {
+		s.next = s.cron.Next(now)
+	}
+	lastRescue := time.Time{}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+
+		for _, s := range r.schedules {
+			if now.Before(s.next) {
+				continue
+			}
+			// The slot time is the key, so each slot is enqueued once however
+			// many runners schedule it
+			key := fmt.Sprintf("cron:%s:%d", s.kind, s.next.Unix())
+			if err := Enqueue(r.db, s.kind, struct{}{}, Options{RunAt: s.next, Key: key, MaxAttempts: 1}); err != nil {
+				log.Printf("Failed to enqueue scheduled job %s: %v", s.kind, err)
+			}
+			s.next = s.cron.Next(now)
+		}
+
+		if now.Sub(lastRescue) >= rescueInterval {
+			r.rescue()
+			lastRescue = now
+		}
+	}
+}
+
+// rescue returns jobs whose runner stopped without finishing them. A job is
+// considered abandoned once it has been running for twice its timeout.
+func (r *Runner) rescue() {
+	staleBefore := time.Now().Add(-2 * r.cfg.JobTimeout)
+	result := r.db.Exec(`UPDATE jobs SET
+		status = CASE WHEN attempts >= max_attempts THEN ? ELSE ? END,
+		last_error = 'abandoned by runner ' || locked_by,
+		locked_at = NULL, locked_by = '', updated_at = NOW()
+		WHERE status = ? AND locked_at < ?`,
+		models.JobStatusDead, models.JobStatusPending, models.JobStatusRunning, staleBefore)
+	if result.Error != nil {
+		log.Printf("Failed to rescue stale jobs: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Rescued %d stale jobs", result.RowsAffected)
+	}
+}
+
+// backoff is the delay before retrying after the given number of attempts,
+// doubling each time, with up to 20% jitter so retries spread out.
+func backoff(attempts int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempts && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) / 5))
+	return delay + jitter
+}
+
+type schedule struct {
+	cron *Cron
+	kind string
+	next time.Time
+}
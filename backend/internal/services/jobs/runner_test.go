@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 10 * time.Second},
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{6, 320 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		// Jitter adds up to a fifth
+		for i := 0; i < 20; i++ {
+			got := backoff(tt.attempts)
+			if got < tt.want || got >= tt.want+tt.want/5 {
+				t.Errorf("backoff(%d) = %v, want %v plus up to 20%%", tt.attempts, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Package lifecycle owns product status changes. Every change of a product's
+// status goes through Apply, which enforces the allowed transitions and keeps
+// the derived columns (is_sold, is_active, sold_at, reserved_for_id) in step.
+package lifecycle
+
+import (
+	"errors"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTransition is returned when the action is not allowed from the
+// product's current status.
+var ErrInvalidTransition = errors.New("invalid product status transition")
+
+// ErrConflict is returned when the product's status changed concurrently.
+var ErrConflict = errors.New("product status changed concurrently")
+
+// ErrRenewalLimit is returned when renewing a product that has used up its
+// renewals.
+var ErrRenewalLimit = errors.New("product renewal limit reached")
+
+// ErrModerated is returned when a seller relists a product a moderator took
+// down.
+var ErrModerated = errors.New("product was taken down by a moderator")
+
+// ErrSellerHidden is returned when a moderator approves a product its seller
+// hid.
+var ErrSellerHidden = errors.New("product was hidden by its seller")
+
+type Action string
+
+const (
+	ActionPublish  Action = "publish"
+	ActionReserve  Action = "reserve"
+	ActionMarkSold Action = "mark-sold"
+	ActionRelist   Action = "relist"
+	ActionHide     Action = "hide"
+	ActionExpire   Action = "expire"
+	ActionRemove   Action = "remove"
+	ActionRestore  Action = "restore"
+	ActionRenew    Action = "renew"
+	ActionSubmit   Action = "submit"
+	ActionApprove  Action = "approve"
+	ActionTakeDown Action = "take-down"
+)
+
+type transition struct {
+	from []models.ProductStatus
+	to   models.ProductStatus
+}
+
+// transitions is the product lifecycle:
+//
+//	draft → available → reserved → sold
+//
+// Sellers can hide and relist their listings, listings expire after a while
+// unless renewed, and moderators can take down or remove any listing and
+// restore it as hidden. New sellers' listings may need a moderator's approval
+// before they go on the market.
+var transitions = map[Action]transition{
+	ActionPublish: {
+		from: []models.ProductStatus{models.ProductStatusDraft},
+		to:   models.ProductStatusAvailable,
+	},
+	ActionSubmit: {
+		from: []models.ProductStatus{models.ProductStatusDraft},
+		to:   models.ProductStatusPendingReview,
+	},
+	ActionApprove: {
+		from: []models.ProductStatus{models.ProductStatusPendingReview, models.ProductStatusHidden},
+		to:   models.ProductStatusAvailable,
+	},
+	ActionReserve: {
+		from: []models.ProductStatus{models.ProductStatusAvailable},
+		to:   models.ProductStatusReserved,
+	},
+	ActionMarkSold: {
+		from: []models.ProductStatus{models.ProductStatusAvailable, models.ProductStatusReserved},
+		to:   models.ProductStatusSold,
+	},
+	ActionRelist: {
+		from: []models.ProductStatus{models.ProductStatusReserved, models.ProductStatusHidden},
+		to:   models.ProductStatusAvailable,
+	},
+	// Renewing counts against the renewal limit, so expired listings come
+	// back through it rather than through relist
+	ActionRenew: {
+		from: []models.ProductStatus{models.ProductStatusAvailable, models.ProductStatusExpired},
+		to:   models.ProductStatusAvailable,
+	},
+	ActionHide: {
+		from: []models.ProductStatus{models.ProductStatusAvailable, models.ProductStatusReserved},
+		to:   models.ProductStatusHidden,
+	},
+	// Taking a listing down hides it until a moderator approves it again
+	ActionTakeDown: {
+		from: []models.ProductStatus{
+			models.ProductStatusPendingReview, models.ProductStatusAvailable, models.ProductStatusReserved,
+			models.ProductStatusHidden, models.ProductStatusExpired,
+		},
+		to: models.ProductStatusHidden,
+	},
+	ActionExpire: {
+		from: []models.ProductStatus{models.ProductStatusAvailable},
+		to:   models.ProductStatusExpired,
+	},
+	ActionRemove: {
+		from: []models.ProductStatus{
+			models.ProductStatusDraft, models.ProductStatusPendingReview, models.ProductStatusAvailable,
+			models.ProductStatusReserved, models.ProductStatusSold, models.ProductStatusHidden,
+			models.ProductStatusExpired,
+		},
+		to: models.ProductStatusRemoved,
+	},
+	ActionRestore: {
+		from: []models.ProductStatus{models.ProductStatusRemoved},
+		to:   models.ProductStatusHidden,
+	},
+}
+
+// Next returns the status the action leads to from the given status.
+func Next(from models.ProductStatus, action Action) (models.ProductStatus, error) {
+	t, ok := transitions[action]
+	if !ok {
+		return "", ErrInvalidTransition
+	}
+	for _, status := range t.from {
+		if status == from {
+			return t.to, nil
+		}
+	}
+	return "", ErrInvalidTransition
+}
+
+// Options carry the details some actions need.
+type Options struct {
+	// BuyerID is who the product is reserved for or sold to
+	BuyerID *uint
+	// Reason explains why a moderator took down or removed the product
+	Reason string
+}
+
+// Apply moves the product through the action inside db, which should be a
+// transaction. The update only succeeds if the product still has the status
+// it was loaded with. On success the product is updated in place.
+func Apply(db *gorm.DB, product *models.Product, action Action, opts Options) error {
+	to, err := Next(product.Status, action)
+	if err != nil {
+		return err
+	}
+
+	if action == ActionRelist && product.ModeratedAt != nil {
+		return ErrModerated
+	}
+
+	query := db.Model(&models.Product{}).Where("id = ? AND status = ?", product.ID, product.Status)
+
+	// Moderators approve hidden listings only when they were taken down;
+	// listings the seller hid stay hidden until the seller relists them
+	if action == ActionApprove && product.Status == models.ProductStatusHidden {
+		if product.ModeratedAt == nil {
+			return ErrSellerHidden
+		}
+		query = query.Where("moderated_at IS NOT NULL")
+	}
+	if action == ActionRenew {
+		if product.RenewalCount >= config.AppConfig.ListingMaxRenewals {
+			return ErrRenewalLimit
+		}
+		query = query.Where("renewal_count = ?", product.RenewalCount)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":    to,
+		"is_sold":   to == models.ProductStatusSold,
+		"is_active": to.IsListed(),
+	}
+
+	moderatedAt, moderationReason := product.ModeratedAt, product.ModerationReason
+	switch action {
+	case ActionTakeDown, ActionRemove:
+		moderatedAt, moderationReason = &now, opts.Reason
+	case ActionApprove:
+		moderatedAt, moderationReason = nil, ""
+	}
+	updates["moderated_at"] = moderatedAt
+	updates["moderation_reason"] = moderationReason
+
+	soldAt := product.SoldAt
+	reservedFor := product.ReservedForID
+	switch to {
+	case models.ProductStatusSold:
+		soldAt = &now
+		if opts.BuyerID != nil {
+			reservedFor = opts.BuyerID
+		}
+	case models.ProductStatusReserved:
+		reservedFor = opts.BuyerID
+	case models.ProductStatusAvailable:
+		soldAt = nil
+		reservedFor = nil
+		// Releasing a reservation or renewing a live listing is not a new
+		// listing; saved searches only match products that are published or
+		// re-listed
+		if product.Status != models.ProductStatusReserved && product.Status != models.ProductStatusAvailable {
+			updates["listed_at"] = now
+		}
+		// A new listing period starts on publishing and renewing, and when a
+		// product returns after its period ran out
+		if action == ActionPublish || action == ActionRenew || product.ExpiresAt == nil || !product.ExpiresAt.After(now) {
+			expiresAt, err := ExpiresAt(db, product.CategoryID, now)
+			if err != nil {
+				return err
+			}
+			updates["expires_at"] = expiresAt
+			updates["expiry_warned_at"] = nil
+		}
+		if action == ActionRenew {
+			updates["renewal_count"] = product.RenewalCount + 1
+		}
+	case models.ProductStatusRemoved:
+		// Keep the sale details of removed listings for the record
+	default:
+		reservedFor = nil
+	}
+	updates["sold_at"] = soldAt
+	updates["reserved_for_id"] = reservedFor
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+
+	if err := settleOffers(db, product, to, reservedFor, now); err != nil {
+		return err
+	}
+
+	product.Status = to
+	product.IsSold = to == models.ProductStatusSold
+	product.IsActive = to.IsListed()
+	product.SoldAt = soldAt
+	product.ReservedForID = reservedFor
+	product.ModeratedAt = moderatedAt
+	product.ModerationReason = moderationReason
+	if listedAt, ok := updates["listed_at"].(time.Time); ok {
+		product.ListedAt = &listedAt
+	}
+	if expiresAt, ok := updates["expires_at"].(time.Time); ok {
+		product.ExpiresAt = &expiresAt
+		product.ExpiryWarnedAt = nil
+	}
+	if renewals, ok := updates["renewal_count"].(int); ok {
+		product.RenewalCount = renewals
+	}
+	return nil
+}
+
+// ExpiresAt returns when a listing in the category published at from
+// expires: after the category's listing days, or LISTING_TTL when the
+// category does not set them.
+func ExpiresAt(db *gorm.DB, categoryID uint, from time.Time) (time.Time, error) {
+	var category models.Category
+	if err := db.Unscoped().Select("id", "listing_days").First(&category, categoryID).Error; err != nil {
+		return time.Time{}, err
+	}
+	if category.ListingDays != nil && *category.ListingDays > 0 {
+		return from.AddDate(0, 0, *category.ListingDays), nil
+	}
+	return from.Add(config.AppConfig.ListingTTL), nil
+}
+
+// settleOffers keeps offers consistent with the product: releasing a
+// reservation, or selling to someone else, cancels the accepted offer,
+// removing a sold product completes it, and a product that is sold or removed
+// no longer takes offers.
+func settleOffers(db *gorm.DB, product *models.Product, to models.ProductStatus, buyerID *uint, now time.Time) error {
+	if product.Status == models.ProductStatusSold && to != models.ProductStatusSold {
+		err := db.Model(&models.Offer{}).
+			Where("product_id = ? AND status = ?", product.ID, models.OfferStatusAccepted).
+			Update("status", models.OfferStatusCompleted).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if product.Status == models.ProductStatusReserved {
+		accepted := db.Model(&models.Offer{}).
+			Where("product_id = ? AND status = ?", product.ID, models.OfferStatusAccepted)
+		if to == models.ProductStatusSold && buyerID != nil {
+			accepted = accepted.Where("buyer_id <> ?", *buyerID)
+		}
+		err := accepted.Updates(map[string]interface{}{"status": models.OfferStatusCancelled, "responded_at": now}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if to == models.ProductStatusSold || to == models.ProductStatusRemoved {
+		return db.Model(&models.Offer{}).
+			Where("product_id = ? AND status IN ?", product.ID, []models.OfferStatus{models.OfferStatusPending, models.OfferStatusCountered}).
+			Updates(map[string]interface{}{"status": models.OfferStatusDeclined, "responded_at": now}).Error
+	}
+	return nil
+}
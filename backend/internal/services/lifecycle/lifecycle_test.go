@@ -0,0 +1,65 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+
+	"bech-do-backend/internal/models"
+)
+
+const (
+	draft         = models.ProductStatusDraft
+	pendingReview = models.ProductStatusPendingReview
+	available     = models.ProductStatusAvailable
+	reserved      = models.ProductStatusReserved
+	sold          = models.ProductStatusSold
+	hidden        = models.ProductStatusHidden
+	expired       = models.ProductStatusExpired
+	removed       = models.ProductStatusRemoved
+)
+
+var allStatuses = []models.ProductStatus{draft, pendingReview, available, reserved, sold, hidden, expired, removed}
+
+func TestNext(t *testing.T) {
+	// The status each action leads to from each status it is allowed from;
+	// every other combination is an invalid transition
+	tests := map[Action]map[models.ProductStatus]models.ProductStatus{
+		ActionPublish:  {draft: available},
+		ActionSubmit:   {draft: pendingReview},
+		ActionApprove:  {pendingReview: available, hidden: available},
+		ActionReserve:  {available: reserved},
+		ActionMarkSold: {available: sold, reserved: sold},
+		ActionRelist:   {reserved: available, hidden: available},
+		ActionRenew:    {available: available, expired: available},
+		ActionHide:     {available: hidden, reserved: hidden},
+		ActionTakeDown: {pendingReview: hidden, available: hidden, reserved: hidden, hidden: hidden, expired: hidden},
+		ActionExpire:   {available: expired},
+		ActionRemove: {
+			draft: removed, pendingReview: removed, available: removed, reserved: removed,
+			sold: removed, hidden: removed, expired: removed,
+		},
+		ActionRestore: {removed: hidden},
+	}
+	if len(tests) != len(transitions) {
+		t.Fatalf("test table covers %d actions, lifecycle has %d", len(tests), len(transitions))
+	}
+
+	for action, allowed := range tests {
+		for _, from := range allStatuses {
+			got, err := Next(from, action)
+			want, ok := allowed[from]
+			switch {
+			case !ok && !errors.Is(err, ErrInvalidTransition):
+				t.Errorf("Next(%s, %s) = %q, %v; want ErrInvalidTransition", from, action, got, err)
+			case ok && (err != nil || got != want):
+				t.Errorf("Next(%s, %s) = %q, %v; want %q", from, action, got, err, want)
+			}
+		}
+	}
+}
+
+func TestNextUnknownAction(t *testing.T) {
+	if _, err := Next(available, Action("delete")); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Next with an unknown action = %v, want ErrInvalidTransition", err)
+	}
+}
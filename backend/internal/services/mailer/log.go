@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogMailer writes emails to the server log, or to .eml files in Dir when it
+// is set. It is meant for local development and tests.
+type LogMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if m.Dir == "" {
+		log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), recipient)
+	return os.WriteFile(filepath.Join(m.Dir, name), buildMessage(m.From, msg), 0o644)
+}
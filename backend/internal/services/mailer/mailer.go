@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"log"
+
+	"bech-do-backend/internal/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer selected by MAIL_DRIVER. Unknown drivers fall back
+// to the log mailer so that local development never sends real email.
+func New(cfg *config.Config) Mailer {
+	switch cfg.MailDriver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	case "log", "file", "":
+		return &LogMailer{Dir: cfg.MailOutputDir, From: cfg.MailFrom}
+	default:
+		log.Printf("Unknown MAIL_DRIVER %q, falling back to log mailer", cfg.MailDriver)
+		return &LogMailer{Dir: cfg.MailOutputDir, From: cfg.MailFrom}
+	}
+}
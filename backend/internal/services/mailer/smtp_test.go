@@ -0,0 +1,118 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts one connection and plays a minimal SMTP server,
+// recording the commands and message it receives. With silent set it
+// accepts the connection and never answers.
+func fakeSMTPServer(t *testing.T, silent bool) (host, port string, received <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if silent {
+			time.Sleep(5 * time.Second)
+			return
+		}
+
+		var got []string
+		defer func() { lines <- got }()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			got = append(got, line)
+			switch {
+			case inData && line == ".":
+				inData = false
+				reply("250 queued")
+			case inData:
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case line == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case line == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(listener.Addr().String())
+	return host, port, lines
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	tests := []struct {
+		from, wantEnvelope, wantHeader string
+	}{
+		{"Bech-Do <no-reply@bechdo.com>", "MAIL FROM:<no-reply@bechdo.com>", `From: "Bech-Do" <no-reply@bechdo.com>`},
+		{"no-reply@bechdo.com", "MAIL FROM:<no-reply@bechdo.com>", "From: <no-reply@bechdo.com>"},
+	}
+	for _, tt := range tests {
+		host, port, received := fakeSMTPServer(t, false)
+		m := &SMTPMailer{Host: host, Port: port, From: tt.from}
+
+		err := m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hi", Body: "Hello"})
+		if err != nil {
+			t.Fatalf("Send with MAIL_FROM %q: %v", tt.from, err)
+		}
+
+		lines := strings.Join(<-received, "\n")
+		for _, want := range []string{tt.wantEnvelope, "RCPT TO:<user@example.com>", tt.wantHeader, "Subject: Hi"} {
+			if !strings.Contains(lines, want) {
+				t.Errorf("MAIL_FROM %q: server did not receive %q in:\n%s", tt.from, want, lines)
+			}
+		}
+	}
+}
+
+func TestSMTPMailerSendRejectsInvalidFrom(t *testing.T) {
+	m := &SMTPMailer{Host: "127.0.0.1", Port: "1", From: "not an address"}
+	if err := m.Send(context.Background(), Message{To: "user@example.com"}); err == nil {
+		t.Error("Send with an invalid MAIL_FROM succeeded, want an error")
+	}
+}
+
+func TestSMTPMailerSendStopsAtDeadline(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t, true)
+	m := &SMTPMailer{Host: host, Port: port, From: "no-reply@bechdo.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := m.Send(ctx, Message{To: "user@example.com"}); err == nil {
+		t.Fatal("Send to a silent server succeeded, want an error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send took %v, want it to stop at the context deadline", elapsed)
+	}
+}
@@ -0,0 +1,281 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/events"
+)
+
+// maxDigestItems is how many listings a digest email names.
+const maxDigestItems = 20
+
+var builders = map[string]builder{
+	events.MessageCreated:       buildMessage,
+	events.OfferCreated:         buildOfferReceived,
+	events.TransactionCreated:   buildPurchase,
+	events.FavoriteSold:         buildFavoriteSold,
+	events.FavoritePriceChanged: buildPriceDrop,
+	events.ListingExpiring:      buildListingExpiring,
+	events.ModerationAction:     buildModerationAction,
+	events.SavedSearchMatched:   buildSavedSearchMatched,
+	events.SavedSearchDigest:    buildSavedSearchDigest,
+}
+
+// buildMessage notifies the recipient of a message, not its sender.
+func buildMessage(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var data struct {
+		ConversationID uint `json:"conversationId"`
+		ProductID      uint `json:"productId"`
+		Message        struct {
+			SenderID uint   `json:"senderId"`
+			Body     string `json:"body"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+
+	var recipients []uint
+	for _, userID := range event.UserIDs {
+		if userID != data.Message.SenderID {
+			recipients = append(recipients, userID)
+		}
+	}
+
+	sender, err := s.displayName(data.Message.SenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypeMessage,
+		UserIDs: recipients,
+		Title:   "New message from " + sender,
+		Body:    preview(data.Message.Body, 140),
+		Link:    fmt.Sprintf("/messages/%d", data.ConversationID),
+		Data:    map[string]interface{}{"conversationId": data.ConversationID, "productId": data.ProductID},
+	}, nil
+}
+
+func buildOfferReceived(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var offer models.Offer
+	if err := json.Unmarshal(payload, &offer); err != nil {
+		return nil, err
+	}
+
+	title, err := s.productTitle(offer.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	buyer, err := s.displayName(offer.BuyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypeOfferReceived,
+		UserIDs: event.UserIDs,
+		Title:   "New offer on " + title,
+		Body:    fmt.Sprintf("%s offered %s for %s.", buyer, rupees(offer.Amount), title),
+		Link:    fmt.Sprintf("/offers/%d", offer.ID),
+		Data:    map[string]interface{}{"offerId": offer.ID, "productId": offer.ProductID, "amount": offer.Amount},
+	}, nil
+}
+
+// buildPurchase tells the buyer that the seller marked the listing sold to
+// them.
+func buildPurchase(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var transaction models.Transaction
+	if err := json.Unmarshal(payload, &transaction); err != nil {
+		return nil, err
+	}
+
+	title, err := s.productTitle(transaction.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypeListingSold,
+		UserIDs: event.UserIDs,
+		Title:   title + " was sold to you",
+		Body:    fmt.Sprintf("The seller marked %s as sold to you for %s. Confirm once you have received it.", title, rupees(transaction.Price)),
+		Link:    fmt.Sprintf("/transactions/%d", transaction.ID),
+		Data:    map[string]interface{}{"transactionId": transaction.ID, "productId": transaction.ProductID},
+	}, nil
+}
+
+func buildFavoriteSold(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var data struct {
+		ProductID uint   `json:"productId"`
+		Title     string `json:"title"`
+	}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypeListingSold,
+		UserIDs: event.UserIDs,
+		Title:   data.Title + " has been sold",
+		Body:    fmt.Sprintf("%s, which you saved, is no longer available.", data.Title),
+		Link:    fmt.Sprintf("/products/%d", data.ProductID),
+		Data:    map[string]interface{}{"productId": data.ProductID},
+	}, nil
+}
+
+// buildPriceDrop only notifies about price cuts; increases are not news to
+// favoriters.
+func buildPriceDrop(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var data struct {
+		ProductID uint    `json:"productId"`
+		Title     string  `json:"title"`
+		OldPrice  float64 `json:"oldPrice"`
+		NewPrice  float64 `json:"newPrice"`
+	}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+	if data.NewPrice >= data.OldPrice {
+		return nil, nil
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypePriceDrop,
+		UserIDs: event.UserIDs,
+		Title:   "Price drop on " + data.Title,
+		Body:    fmt.Sprintf("%s is now %s, down from %s.", data.Title, rupees(data.NewPrice), rupees(data.OldPrice)),
+		Link:    fmt.Sprintf("/products/%d", data.ProductID),
+		Data:    map[string]interface{}{"productId": data.ProductID, "oldPrice": data.OldPrice, "newPrice": data.NewPrice},
+	}, nil
+}
+
+func buildListingExpiring(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var data struct {
+		ProductID uint      `json:"productId"`
+		Title     string    `json:"title"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypeListingExpiring,
+		UserIDs: event.UserIDs,
+		Title:   data.Title + " expires soon",
+		Body:    fmt.Sprintf("Your listing %s expires on %s. Renew it to keep it on the market.", data.Title, data.ExpiresAt.Format("2 Jan 2006")),
+		Link:    fmt.Sprintf("/products/%d", data.ProductID),
+		Data:    map[string]interface{}{"productId": data.ProductID, "expiresAt": data.ExpiresAt},
+	}, nil
+}
+
+func buildModerationAction(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var data struct {
+		ProductID uint   `json:"productId"`
+		Title     string `json:"title"`
+		Action    string `json:"action"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Your listing %s was %s by a moderator.", data.Title, data.Action)
+	if data.Reason != "" {
+		body += " Reason: " + data.Reason
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypeModeration,
+		UserIDs: event.UserIDs,
+		Title:   "Your listing " + data.Title + " was " + data.Action,
+		Body:    body,
+		Link:    fmt.Sprintf("/products/%d", data.ProductID),
+		Data:    map[string]interface{}{"productId": data.ProductID, "action": data.Action, "reason": data.Reason},
+	}, nil
+}
+
+type savedSearchData struct {
+	SavedSearchID uint   `json:"savedSearchId"`
+	Name          string `json:"name"`
+	ProductIDs    []uint `json:"productIds"`
+	Count         int    `json:"count"`
+	Products      []struct {
+		ID    uint    `json:"id"`
+		Title string  `json:"title"`
+		Price float64 `json:"price"`
+	} `json:"products"`
+}
+
+func buildSavedSearchMatched(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var data savedSearchData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+
+	return &Notice{
+		Type:    models.NotificationTypeSavedSearch,
+		UserIDs: event.UserIDs,
+		Title:   fmt.Sprintf("New listings for \"%s\"", data.Name),
+		Body:    listingCount(data.Count) + " matching your saved search.",
+		Link:    fmt.Sprintf("/saved-searches/%d", data.SavedSearchID),
+		Data:    map[string]interface{}{"savedSearchId": data.SavedSearchID, "productIds": data.ProductIDs},
+	}, nil
+}
+
+// buildSavedSearchDigest lists the matched listings in the email; the in-app
+// entry only carries the count.
+func buildSavedSearchDigest(s *Service, event events.Event, payload []byte) (*Notice, error) {
+	var data savedSearchData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+
+	notice := &Notice{
+		Type:    models.NotificationTypeSavedSearch,
+		UserIDs: event.UserIDs,
+		Title:   fmt.Sprintf("New listings for \"%s\"", data.Name),
+		Body:    listingCount(data.Count) + " matching your saved search today.",
+		Link:    fmt.Sprintf("/saved-searches/%d", data.SavedSearchID),
+		Data:    map[string]interface{}{"savedSearchId": data.SavedSearchID, "productIds": data.ProductIDs},
+	}
+
+	var b strings.Builder
+	b.WriteString(notice.Body + "\n\n")
+	for i, product := range data.Products {
+		if i == maxDigestItems {
+			fmt.Fprintf(&b, "...and %d more.\n\n", len(data.Products)-maxDigestItems)
+			break
+		}
+		fmt.Fprintf(&b, "%s - %s\n%s/products/%d\n\n", product.Title, rupees(product.Price), s.frontendURL, product.ID)
+	}
+	b.WriteString("See all matches:")
+	notice.EmailBody = b.String()
+
+	return notice, nil
+}
+
+func listingCount(n int) string {
+	if n == 1 {
+		return "1 new listing"
+	}
+	return fmt.Sprintf("%d new listings", n)
+}
+
+func rupees(amount float64) string {
+	return fmt.Sprintf("Rs. %.0f", amount)
+}
+
+// preview shortens text to at most n runes.
+func preview(text string, n int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
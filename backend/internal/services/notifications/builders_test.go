@@ -0,0 +1,118 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/events"
+)
+
+func TestBuildPriceDrop(t *testing.T) {
+	tests := []struct {
+		name               string
+		oldPrice, newPrice float64
+		wantNotice         bool
+	}{
+		{"price drop", 1000, 800, true},
+		{"price rise", 1000, 1200, false},
+		{"same price", 1000, 1000, false},
+	}
+	for _, tt := range tests {
+		event := events.Event{Type: events.FavoritePriceChanged, UserIDs: []uint{2, 3}}
+		payload := fmt.Sprintf(`{"productId": 7, "title": "Desk", "oldPrice": %v, "newPrice": %v}`, tt.oldPrice, tt.newPrice)
+
+		notice, err := buildPriceDrop(&Service{}, event, []byte(payload))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if (notice != nil) != tt.wantNotice {
+			t.Errorf("%s: notice = %v, want one: %v", tt.name, notice, tt.wantNotice)
+			continue
+		}
+		if notice == nil {
+			continue
+		}
+		if notice.Type != models.NotificationTypePriceDrop || len(notice.UserIDs) != 2 {
+			t.Errorf("%s: notice of type %s for %v, want a price drop for [2 3]", tt.name, notice.Type, notice.UserIDs)
+		}
+		if want := "Desk is now Rs. 800, down from Rs. 1000."; notice.Body != want {
+			t.Errorf("%s: body = %q, want %q", tt.name, notice.Body, want)
+		}
+		if notice.Link != "/products/7" {
+			t.Errorf("%s: link = %q, want /products/7", tt.name, notice.Link)
+		}
+	}
+}
+
+func TestBuildSavedSearchDigest(t *testing.T) {
+	tests := []struct {
+		products  int
+		wantItems int
+		wantMore  string
+	}{
+		{1, 1, ""},
+		{maxDigestItems, maxDigestItems, ""},
+		{maxDigestItems + 5, maxDigestItems, "...and 5 more."},
+	}
+	for _, tt := range tests {
+		var products []string
+		for i := 1; i <= tt.products; i++ {
+			products = append(products, fmt.Sprintf(`{"id": %d, "title": "Chair %d", "price": 500}`, i, i))
+		}
+		payload := fmt.Sprintf(`{"savedSearchId": 4, "name": "chairs", "count": %d, "products": [%s]}`,
+			tt.products, strings.Join(products, ","))
+
+		s := &Service{frontendURL: "https://bechdo.example"}
+		notice, err := buildSavedSearchDigest(s, events.Event{UserIDs: []uint{1}}, []byte(payload))
+		if err != nil {
+			t.Fatalf("%d products: %v", tt.products, err)
+		}
+
+		if got := strings.Count(notice.EmailBody, "https://bechdo.example/products/"); got != tt.wantItems {
+			t.Errorf("%d products: email links %d listings, want %d", tt.products, got, tt.wantItems)
+		}
+		if tt.wantMore != "" && !strings.Contains(notice.EmailBody, tt.wantMore) {
+			t.Errorf("%d products: email does not say %q:\n%s", tt.products, tt.wantMore, notice.EmailBody)
+		}
+		if !strings.HasPrefix(notice.EmailBody, notice.Body) {
+			t.Errorf("%d products: email does not start with the in-app body %q", tt.products, notice.Body)
+		}
+	}
+}
+
+func TestListingCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "1 new listing"},
+		{2, "2 new listings"},
+		{0, "0 new listings"},
+	}
+	for _, tt := range tests {
+		if got := listingCount(tt.n); got != tt.want {
+			t.Errorf("listingCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"  padded  ", 10, "padded"},
+		{"exactly ten", 11, "exactly ten"},
+		{"a longer message", 9, "a longer…"},
+		{"नमस्ते दुनिया", 7, "नमस्ते…"},
+	}
+	for _, tt := range tests {
+		if got := preview(tt.text, tt.n); got != tt.want {
+			t.Errorf("preview(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package notifications turns domain events into entries in the users'
+// notification centers and emails, according to each user's preferences.
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/events"
+	"bech-do-backend/internal/services/jobs"
+	"bech-do-backend/internal/services/mailer"
+	"bech-do-backend/internal/services/realtime"
+
+	"gorm.io/gorm"
+)
+
+// Notice is the content of a notification for a set of recipients.
+type Notice struct {
+	Type    models.NotificationType
+	UserIDs []uint
+	Title   string
+	Body    string
+	// Link is a frontend path
+	Link string
+	Data map[string]interface{}
+	// EmailBody replaces Body in the email when set
+	EmailBody string
+}
+
+// builder renders the notice for an event from its JSON payload. A nil
+// notice means the event does not warrant a notification.
+type builder func(s *Service, event events.Event, payload []byte) (*Notice, error)
+
+type Service struct {
+	db          *gorm.DB
+	frontendURL string
+}
+
+func NewService(cfg *config.Config, db *gorm.DB) *Service {
+	return &Service{db: db, frontendURL: cfg.FrontendURL}
+}
+
+// delivery is the payload of a notification delivery job: the event, with
+// its data in the JSON shape the builders read.
+type delivery struct {
+	Type    string          `json:"type"`
+	UserIDs []uint          `json:"userIds"`
+	Data    json.RawMessage `json:"data"`
+	At      time.Time       `json:"at"`
+}
+
+// Handle is an events.Handler that queues the delivery of the events that
+// have a builder, so that emitting an event does not wait on notifications.
+func (s *Service) Handle(ctx context.Context, event events.Event) {
+	if _, ok := builders[event.Type]; !ok || len(event.UserIDs) == 0 {
+		return
+	}
+
+	// Payloads are structs or maps depending on the producer; JSON is the
+	// shape they share
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event.Type, err)
+		return
+	}
+
+	job := delivery{Type: event.Type, UserIDs: event.UserIDs, Data: payload, At: event.At}
+	if err := jobs.Enqueue(s.db.WithContext(ctx), jobs.KindDeliverNotification, job, jobs.Options{}); err != nil {
+		log.Printf("Failed to queue %s notification: %v", event.Type, err)
+	}
+}
+
+// DeliverJob is the job that notifies the recipients of a queued event.
+func (s *Service) DeliverJob(ctx context.Context, job *models.Job) error {
+	var d delivery
+	if err := job.Decode(&d); err != nil {
+		return jobs.Permanent(err)
+	}
+	build, ok := builders[d.Type]
+	if !ok {
+		return jobs.Permanent(fmt.Errorf("notifications: no builder for %s events", d.Type))
+	}
+
+	event := events.Event{Type: d.Type, UserIDs: d.UserIDs, At: d.At}
+	notice, err := build(s, event, d.Data)
+	if err != nil {
+		return err
+	}
+	if notice == nil {
+		return nil
+	}
+	return s.Deliver(ctx, notice)
+}
+
+// Deliver stores the notice in the inbox of each active recipient who wants
+// it in-app and emails those who want it by email.
+func (s *Service) Deliver(ctx context.Context, notice *Notice) error {
+	if len(notice.UserIDs) == 0 {
+		return nil
+	}
+
+	var users []models.User
+	err := s.db.WithContext(ctx).
+		Select("id", "email", "first_name", "notification_preferences").
+		Where("id IN ? AND is_active = ?", notice.UserIDs, true).
+		Find(&users).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		user := &users[i]
+		pref := user.NotificationPreferences.For(notice.Type)
+
+		if pref.InApp {
+			notification := models.Notification{
+				UserID: user.ID,
+				Type:   notice.Type,
+				Title:  notice.Title,
+				Body:   notice.Body,
+				Link:   notice.Link,
+				Data:   notice.Data,
+			}
+			if err := s.db.WithContext(ctx).Create(&notification).Error; err != nil {
+				log.Printf("Failed to store notification for user %d: %v", user.ID, err)
+			} else {
+				realtime.Publish("notification.created", notification, user.ID)
+			}
+		}
+
+		if pref.Email && user.Email != "" {
+			err := jobs.Enqueue(s.db.WithContext(ctx), jobs.KindSendEmail, s.emailMessage(user, notice), jobs.Options{})
+			if err != nil {
+				log.Printf("Failed to queue notification email to user %d: %v", user.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) emailMessage(user *models.User, notice *Notice) mailer.Message {
+	body := notice.Body
+	if notice.EmailBody != "" {
+		body = notice.EmailBody
+	}
+
+	link := ""
+	if notice.Link != "" {
+		link = "\n\n" + s.frontendURL + notice.Link
+	}
+
+	return mailer.Message{
+		To:      user.Email,
+		Subject: notice.Title,
+		Body: fmt.Sprintf("Hi %s,\n\n%s%s\n\nYou can choose which emails you receive in your notification settings.\n",
+			user.FirstName, body, link),
+	}
+}
+
+// productTitle looks up a product's title, including deleted products.
+func (s *Service) productTitle(productID uint) (string, error) {
+	var product models.Product
+	err := s.db.Unscoped().Select("id", "title").First(&product, productID).Error
+	return product.Title, err
+}
+
+// displayName looks up the public name of a user.
+func (s *Service) displayName(userID uint) (string, error) {
+	var user models.User
+	err := s.db.Unscoped().Select("id", "first_name", "last_name", "username").First(&user, userID).Error
+	return user.DisplayName(), err
+}
@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a frame to the client
+	writeWait = 10 * time.Second
+	// The client must answer pings within this time
+	pongWait = 60 * time.Second
+	// Pings are sent at this interval, which must be less than pongWait
+	pingPeriod = pongWait * 9 / 10
+	// Clients only send control frames and small pings
+	maxMessageSize = 4096
+	// Events queued for a client before it is treated as too slow
+	sendBufferSize = 64
+)
+
+// Client is one WebSocket connection of an authenticated user.
+type Client struct {
+	hub       *Hub
+	conn      *websocket.Conn
+	userID    uint
+	expiresAt time.Time
+
+	send      chan []byte
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewClient wraps an upgraded connection. The connection is closed when the
+// access token it was opened with expires; clients reconnect with a fresh
+// token.
+func NewClient(hub *Hub, conn *websocket.Conn, userID uint, expiresAt time.Time) *Client {
+	return &Client{
+		hub:       hub,
+		conn:      conn,
+		userID:    userID,
+		expiresAt: expiresAt,
+		send:      make(chan []byte, sendBufferSize),
+		done:      make(chan struct{}),
+	}
+}
+
+// Serve registers the client and pumps frames until the connection closes.
+func (c *Client) Serve() {
+	c.hub.register(c)
+	defer c.hub.unregister(c)
+
+	go c.writePump()
+	c.readPump()
+}
+
+// enqueue queues an event without blocking. A client whose buffer is full is
+// disconnected instead of holding up delivery to everyone else.
+func (c *Client) enqueue(message []byte) {
+	select {
+	case <-c.done:
+	case c.send <- message:
+	default:
+		// Close in the background so the publisher is not held up either
+		go c.close(websocket.CloseTryAgainLater, "client too slow")
+	}
+}
+
+func (c *Client) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		deadline := time.Now().Add(writeWait)
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+		c.conn.Close()
+	})
+}
+
+func (c *Client) readPump() {
+	defer c.close(websocket.CloseNormalClosure, "")
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	// Incoming frames are only read to process control frames and detect
+	// disconnects
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	expiry := time.NewTimer(time.Until(c.expiresAt))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-expiry.C:
+			c.close(websocket.ClosePolicyViolation, "token expired")
+			return
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Event is sent to connected clients as a JSON text frame.
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+	At   time.Time   `json:"at"`
+}
+
+// envelope is what travels over the pub/sub: the event plus its recipients.
+type envelope struct {
+	UserIDs []uint          `json:"userIds"`
+	Event   json.RawMessage `json:"event"`
+}
+
+// Hub tracks the WebSocket clients connected to this instance and delivers
+// events published by any instance to the clients of their recipients.
+type Hub struct {
+	pubsub PubSub
+
+	mu      sync.RWMutex
+	clients map[uint]map[*Client]struct{}
+}
+
+func NewHub(pubsub PubSub) *Hub {
+	return &Hub{
+		pubsub:  pubsub,
+		clients: make(map[uint]map[*Client]struct{}),
+	}
+}
+
+// Run delivers events from the pub/sub until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) error {
+	return h.pubsub.Subscribe(ctx, h.deliver)
+}
+
+// Publish sends an event to every connection of the given users, on any
+// server instance.
+func (h *Hub) Publish(ctx context.Context, eventType string, data interface{}, userIDs ...uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	event, err := json.Marshal(Event{Type: eventType, Data: data, At: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope{UserIDs: userIDs, Event: event})
+	if err != nil {
+		return err
+	}
+	return h.pubsub.Publish(ctx, payload)
+}
+
+func (h *Hub) register(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[client.userID] == nil {
+		h.clients[client.userID] = make(map[*Client]struct{})
+	}
+	h.clients[client.userID][client] = struct{}{}
+}
+
+func (h *Hub) unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if clients, ok := h.clients[client.userID]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(h.clients, client.userID)
+		}
+	}
+}
+
+func (h *Hub) deliver(payload []byte) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		log.Printf("Dropping malformed realtime payload: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, userID := range env.UserIDs {
+		for client := range h.clients[userID] {
+			client.enqueue(env.Event)
+		}
+	}
+}
+
+var defaultHub *Hub
+
+// SetDefault installs the hub used by Publish.
+func SetDefault(hub *Hub) {
+	defaultHub = hub
+}
+
+// Default returns the hub installed by SetDefault, or nil.
+func Default() *Hub {
+	return defaultHub
+}
+
+// Publish sends an event through the default hub. It is a no-op when no hub
+// is installed, and failures are logged rather than returned, since realtime
+// delivery is best-effort.
+func Publish(eventType string, data interface{}, userIDs ...uint) {
+	if defaultHub == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := defaultHub.Publish(ctx, eventType, data, userIDs...); err != nil {
+		log.Printf("Failed to publish %s event: %v", eventType, err)
+	}
+}
@@ -0,0 +1,69 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const defaultChannel = "bechdo_events"
+
+// PostgresPubSub shares events between server instances with LISTEN/NOTIFY.
+// Payloads are limited to 8000 bytes by PostgreSQL. LISTEN needs a session,
+// so the DSN must not point at a transaction-mode connection pooler.
+type PostgresPubSub struct {
+	db      *gorm.DB
+	dsn     string
+	channel string
+}
+
+func NewPostgresPubSub(db *gorm.DB, dsn, channel string) *PostgresPubSub {
+	return &PostgresPubSub{db: db, dsn: dsn, channel: channel}
+}
+
+func (p *PostgresPubSub) Publish(ctx context.Context, payload []byte) error {
+	return p.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", p.channel, string(payload)).Error
+}
+
+// Subscribe listens on a dedicated connection and reconnects with backoff
+// when it drops. Events published while disconnected are lost.
+func (p *PostgresPubSub) Subscribe(ctx context.Context, handler func([]byte)) error {
+	backoff := time.Second
+	for {
+		err := p.listen(ctx, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Realtime listener disconnected: %v (retrying in %s)", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func (p *PostgresPubSub) listen(ctx context.Context, handler func([]byte)) error {
+	conn, err := pgx.Connect(ctx, p.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handler([]byte(notification.Payload))
+	}
+}
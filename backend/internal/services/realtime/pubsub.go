@@ -0,0 +1,73 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"bech-do-backend/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// PubSub carries events between server instances. Every subscriber receives
+// every published payload.
+type PubSub interface {
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe calls handler for each payload until ctx is cancelled.
+	Subscribe(ctx context.Context, handler func(payload []byte)) error
+}
+
+// NewPubSub returns the pub/sub selected by REALTIME_DRIVER.
+func NewPubSub(cfg *config.Config, db *gorm.DB) PubSub {
+	switch cfg.RealtimeDriver {
+	case "postgres":
+		dsn := cfg.RealtimeDatabaseURL
+		if dsn == "" {
+			dsn = cfg.DatabaseURL
+		}
+		return NewPostgresPubSub(db, dsn, defaultChannel)
+	case "memory", "":
+		return NewMemoryPubSub()
+	default:
+		log.Printf("Unknown REALTIME_DRIVER %q, falling back to in-process pub/sub", cfg.RealtimeDriver)
+		return NewMemoryPubSub()
+	}
+}
+
+// MemoryPubSub delivers events within a single process. Use it when only
+// one server instance is running.
+type MemoryPubSub struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]func([]byte)
+}
+
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{handlers: make(map[int]func([]byte))}
+}
+
+func (p *MemoryPubSub) Publish(ctx context.Context, payload []byte) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, handler := range p.handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+func (p *MemoryPubSub) Subscribe(ctx context.Context, handler func([]byte)) error {
+	p.mu.Lock()
+	id := p.nextID
+	p.nextID++
+	p.handlers[id] = handler
+	p.mu.Unlock()
+
+	<-ctx.Done()
+
+	p.mu.Lock()
+	delete(p.handlers, id)
+	p.mu.Unlock()
+	return ctx.Err()
+}
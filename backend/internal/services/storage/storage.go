@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+
+	"bech-do-backend/internal/config"
+)
+
+// Storage persists uploaded files and returns the public URL they are
+// served from.
+type Storage interface {
+	// Name identifies the backend, e.g. "local" or "cloudinary".
+	Name() string
+	Save(ctx context.Context, key string, r io.Reader, contentType string) (string, error)
+	// Open reads back a stored file. The caller closes it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the storage backend selected by STORAGE_DRIVER.
+func New(cfg *config.Config) Storage {
+	switch cfg.StorageDriver {
+	case "cloudinary":
+		return NewCloudinaryStorage(cfg.CloudinaryName, cfg.CloudinaryKey, cfg.CloudinarySecret)
+	case "local", "":
+		return NewLocalStorage(cfg.UploadDir, strings.TrimRight(cfg.PublicBaseURL, "/")+MediaPrefix)
+	default:
+		log.Printf("Unknown STORAGE_DRIVER %q, falling back to local storage", cfg.StorageDriver)
+		return NewLocalStorage(cfg.UploadDir, strings.TrimRight(cfg.PublicBaseURL, "/")+MediaPrefix)
+	}
+}
@@ -0,0 +1,62 @@
+// Package worker wires the job handlers and schedules shared by the server,
+// when it runs jobs in-process, and the standalone worker binary.
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"bech-do-backend/internal/api/handlers"
+	"bech-do-backend/internal/config"
+	"bech-do-backend/internal/models"
+	"bech-do-backend/internal/services/jobs"
+	"bech-do-backend/internal/services/mailer"
+	"bech-do-backend/internal/services/notifications"
+	"bech-do-backend/internal/services/storage"
+
+	"gorm.io/gorm"
+)
+
+// NewRunner returns a job runner with every handler and schedule registered.
+func NewRunner(cfg *config.Config, db *gorm.DB) (*jobs.Runner, error) {
+	runner := jobs.NewRunner(db, jobs.Config{
+		Workers:      cfg.JobWorkers,
+		PollInterval: cfg.JobPollInterval,
+	})
+
+	m := mailer.New(cfg)
+	runner.Register(jobs.KindSendEmail, func(ctx context.Context, job *models.Job) error {
+		var msg mailer.Message
+		if err := job.Decode(&msg); err != nil {
+			return jobs.Permanent(err)
+		}
+		return m.Send(ctx, msg)
+	})
+	runner.Register(jobs.KindSendPasswordReset, handlers.SendPasswordReset(m))
+	runner.Register(jobs.KindSendVerification, handlers.SendVerification(m))
+	runner.Register(jobs.KindDeliverNotification, notifications.NewService(cfg, db).DeliverJob)
+
+	uploadHandler := handlers.NewUploadHandler(storage.New(cfg))
+	runner.Register(jobs.KindImageVariants, uploadHandler.GenerateVariants)
+	runner.Register(jobs.KindMatchSavedSearches, handlers.MatchSavedSearches)
+	runner.Register(jobs.KindExpireOffers, handlers.ExpireOffers)
+	runner.Register(jobs.KindExpireListings, handlers.ExpireListings)
+	runner.Register(jobs.KindRecordViews, handlers.RecordViews)
+	runner.Register(jobs.KindCleanupJobs, jobs.Cleanup(db, cfg.JobRetention))
+	runner.Register(jobs.KindCleanupLoginThrottles, handlers.CleanupLoginThrottles)
+
+	schedules := []struct{ spec, kind string }{
+		{"@every " + cfg.SavedSearchInterval.String(), jobs.KindMatchSavedSearches},
+		{"*/5 * * * *", jobs.KindExpireOffers},
+		{"*/15 * * * *", jobs.KindExpireListings},
+		{"30 3 * * *", jobs.KindCleanupJobs},
+		{"0 * * * *", jobs.KindCleanupLoginThrottles},
+	}
+	for _, s := range schedules {
+		if err := runner.Schedule(s.spec, s.kind); err != nil {
+			return nil, fmt.Errorf("worker: %w", err)
+		}
+	}
+
+	return runner, nil
+}
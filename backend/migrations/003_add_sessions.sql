@@ -0,0 +1,23 @@
+-- Migration to add server-side sessions for refresh token rotation
+
+CREATE TABLE sessions (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash VARCHAR(64) UNIQUE NOT NULL,
+    previous_token_hash VARCHAR(64),
+    user_agent TEXT,
+    ip_address VARCHAR(64),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX idx_sessions_previous_token_hash ON sessions(previous_token_hash);
+CREATE INDEX idx_sessions_active ON sessions(user_id) WHERE revoked_at IS NULL;
+
+CREATE TRIGGER update_sessions_updated_at BEFORE UPDATE ON sessions
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
@@ -0,0 +1,4 @@
+-- Migration to support email verification
+
+-- Track when the last verification email was sent, used to throttle resends
+ALTER TABLE users ADD COLUMN verification_sent_at TIMESTAMP WITH TIME ZONE;
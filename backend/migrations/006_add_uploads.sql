@@ -0,0 +1,17 @@
+-- Migration to track files uploaded through the storage backend
+
+CREATE TABLE uploads (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    backend VARCHAR(20) NOT NULL,
+    key TEXT NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    content_type VARCHAR(50),
+    size BIGINT,
+    width INTEGER,
+    height INTEGER
+);
+
+CREATE INDEX idx_uploads_user_id ON uploads(user_id);
@@ -0,0 +1,12 @@
+-- Migration to store resized image variants for uploads and products
+
+ALTER TABLE uploads ADD COLUMN variants JSONB DEFAULT '{}'::jsonb;
+ALTER TABLE products ADD COLUMN image_variants JSONB DEFAULT '[]'::jsonb;
+
+-- Existing product images have no variants; point every variant at the original
+UPDATE products
+SET image_variants = COALESCE((
+    SELECT jsonb_agg(jsonb_build_object('original', url, 'full', url, 'card', url, 'thumb', url))
+    FROM jsonb_array_elements_text(products.images) AS url
+), '[]'::jsonb)
+WHERE jsonb_typeof(images) = 'array';
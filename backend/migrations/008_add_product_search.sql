@@ -0,0 +1,44 @@
+-- Migration to add weighted full-text search for products
+
+ALTER TABLE products ADD COLUMN search_vector tsvector;
+
+-- Title ranks above description, which ranks above the category name
+CREATE OR REPLACE FUNCTION product_search_vector(p_title TEXT, p_description TEXT, p_category_id INTEGER)
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(p_title, '')), 'A') ||
+           setweight(to_tsvector('english', COALESCE(p_description, '')), 'B') ||
+           setweight(to_tsvector('english', COALESCE((SELECT name FROM categories WHERE id = p_category_id), '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION update_product_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector = product_search_vector(NEW.title, NEW.description, NEW.category_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_products_search_vector BEFORE INSERT OR UPDATE OF title, description, category_id ON products
+    FOR EACH ROW EXECUTE PROCEDURE update_product_search_vector();
+
+-- Keep products current when a category is renamed
+CREATE OR REPLACE FUNCTION refresh_category_product_search_vectors()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE products
+    SET search_vector = product_search_vector(title, description, category_id)
+    WHERE category_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER refresh_categories_product_search AFTER UPDATE OF name ON categories
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE PROCEDURE refresh_category_product_search_vectors();
+
+-- Backfill existing products without touching updated_at
+ALTER TABLE products DISABLE TRIGGER update_products_updated_at;
+UPDATE products SET search_vector = product_search_vector(title, description, category_id);
+ALTER TABLE products ENABLE TRIGGER update_products_updated_at;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
@@ -0,0 +1,7 @@
+-- Migration to add coordinates to products for "near me" search
+
+ALTER TABLE products ADD COLUMN pin_code VARCHAR(20);
+ALTER TABLE products ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE products ADD COLUMN longitude DOUBLE PRECISION;
+
+CREATE INDEX idx_products_coordinates ON products(latitude, longitude) WHERE latitude IS NOT NULL;
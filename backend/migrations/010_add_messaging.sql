@@ -0,0 +1,44 @@
+-- Migration to add buyer-seller messaging
+
+CREATE TABLE conversations (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    buyer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seller_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_message_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT idx_conversations_product_buyer UNIQUE (product_id, buyer_id),
+    CHECK (buyer_id <> seller_id)
+);
+
+CREATE TABLE messages (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE user_blocks (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    blocker_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT idx_user_blocks_pair UNIQUE (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_conversations_buyer_id ON conversations(buyer_id, last_message_at DESC);
+CREATE INDEX idx_conversations_seller_id ON conversations(seller_id, last_message_at DESC);
+CREATE INDEX idx_messages_conversation_id ON messages(conversation_id, id);
+CREATE INDEX idx_messages_unread ON messages(conversation_id) WHERE read_at IS NULL;
+
+CREATE TRIGGER update_conversations_updated_at BEFORE UPDATE ON conversations
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
@@ -0,0 +1,44 @@
+-- Migration to add offers and product reservations
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_status_check;
+ALTER TABLE products ADD CONSTRAINT products_status_check
+    CHECK (status IN ('available', 'reserved', 'sold', 'hidden'));
+
+-- The buyer a reserved product is held for
+ALTER TABLE products ADD COLUMN reserved_for_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE offers (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    buyer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seller_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    counter_amount DECIMAL(10,2) CHECK (counter_amount > 0),
+    agreed_amount DECIMAL(10,2),
+    message TEXT,
+    counter_message TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'countered', 'accepted', 'declined', 'expired')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    responded_at TIMESTAMP WITH TIME ZONE,
+
+    CHECK (buyer_id <> seller_id)
+);
+
+-- A buyer has at most one open offer per product
+CREATE UNIQUE INDEX idx_offers_open_per_buyer ON offers(product_id, buyer_id)
+    WHERE status IN ('pending', 'countered');
+
+-- A product has at most one accepted offer
+CREATE UNIQUE INDEX idx_offers_accepted_per_product ON offers(product_id)
+    WHERE status = 'accepted';
+
+CREATE INDEX idx_offers_buyer_id ON offers(buyer_id, created_at DESC);
+CREATE INDEX idx_offers_seller_id ON offers(seller_id, created_at DESC);
+CREATE INDEX idx_offers_open_expiry ON offers(expires_at) WHERE status IN ('pending', 'countered');
+
+CREATE TRIGGER update_offers_updated_at BEFORE UPDATE ON offers
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
@@ -0,0 +1,27 @@
+-- Migration to enforce a single product lifecycle
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_status_check;
+ALTER TABLE products ADD CONSTRAINT products_status_check
+    CHECK (status IN ('draft', 'available', 'reserved', 'sold', 'hidden', 'expired', 'removed'));
+
+ALTER TABLE offers DROP CONSTRAINT IF EXISTS offers_status_check;
+ALTER TABLE offers ADD CONSTRAINT offers_status_check
+    CHECK (status IN ('pending', 'countered', 'accepted', 'declined', 'expired', 'cancelled'));
+
+-- Reconcile the legacy flags with the status, which is now authoritative.
+-- Products flagged sold or inactive while still "available" take the flag's
+-- meaning.
+ALTER TABLE products DISABLE TRIGGER update_products_updated_at;
+
+UPDATE products SET status = 'sold'
+WHERE status = 'available' AND is_sold = TRUE;
+
+UPDATE products SET status = 'hidden'
+WHERE status = 'available' AND is_active = FALSE;
+
+UPDATE products SET
+    is_sold = (status = 'sold'),
+    is_active = (status IN ('available', 'reserved')),
+    sold_at = CASE WHEN status = 'sold' THEN COALESCE(sold_at, updated_at) END;
+
+ALTER TABLE products ENABLE TRIGGER update_products_updated_at;
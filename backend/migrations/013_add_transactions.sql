@@ -0,0 +1,28 @@
+-- Migration to record completed sales
+
+CREATE TABLE transactions (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    seller_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    buyer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    offer_id INTEGER REFERENCES offers(id) ON DELETE SET NULL,
+    price DECIMAL(10,2) NOT NULL CHECK (price >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed')),
+    sold_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+
+    CHECK (buyer_id <> seller_id)
+);
+
+-- A sale awaiting confirmation is unique per product
+CREATE UNIQUE INDEX idx_transactions_pending_product ON transactions(product_id)
+    WHERE status = 'pending';
+
+CREATE INDEX idx_transactions_buyer_id ON transactions(buyer_id, sold_at DESC);
+CREATE INDEX idx_transactions_seller_id ON transactions(seller_id, sold_at DESC);
+
+CREATE TRIGGER update_transactions_updated_at BEFORE UPDATE ON transactions
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
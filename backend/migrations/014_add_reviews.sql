@@ -0,0 +1,28 @@
+-- Migration to add reviews between the parties of completed sales
+
+CREATE TABLE reviews (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    reviewer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewer_role VARCHAR(10) NOT NULL CHECK (reviewer_role IN ('buyer', 'seller')),
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL,
+    reply TEXT,
+    replied_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT idx_reviews_transaction_reviewer UNIQUE (transaction_id, reviewer_id),
+    CHECK (reviewer_id <> reviewee_id)
+);
+
+CREATE INDEX idx_reviews_reviewee_id ON reviews(reviewee_id, created_at DESC);
+
+CREATE TRIGGER update_reviews_updated_at BEFORE UPDATE ON reviews
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+-- Aggregate rating of the reviews a user has received
+ALTER TABLE users ADD COLUMN rating DECIMAL(3,2) NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN review_count INTEGER NOT NULL DEFAULT 0;
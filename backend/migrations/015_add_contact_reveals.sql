@@ -0,0 +1,14 @@
+-- Migration to support public seller profiles
+
+CREATE TABLE contact_reveals (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    viewer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_contact_reveals_viewer_id ON contact_reveals(viewer_id, created_at DESC);
+
+-- Storefronts and active listing counts
+CREATE INDEX idx_products_user_status ON products(user_id, status) WHERE deleted_at IS NULL;
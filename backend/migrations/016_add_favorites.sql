@@ -0,0 +1,16 @@
+-- Migration to add favorites
+
+CREATE TABLE favorites (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+
+    CONSTRAINT idx_favorites_user_product UNIQUE (user_id, product_id)
+);
+
+CREATE INDEX idx_favorites_product_id ON favorites(product_id);
+
+-- Maintained by the favorites endpoints
+ALTER TABLE products ADD COLUMN favorite_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,43 @@
+-- Migration to add saved searches
+
+CREATE TABLE saved_searches (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    filters JSONB NOT NULL DEFAULT '{}',
+    alert_mode VARCHAR(20) NOT NULL DEFAULT 'instant' CHECK (alert_mode IN ('instant', 'daily')),
+    -- Listings after this point have not been matched yet
+    last_matched_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_notified_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_saved_searches_user_id ON saved_searches(user_id);
+
+CREATE TRIGGER update_saved_searches_updated_at BEFORE UPDATE ON saved_searches
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TABLE saved_search_matches (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    saved_search_id INTEGER NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    notified_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT idx_saved_search_matches_search_product UNIQUE (saved_search_id, product_id)
+);
+
+CREATE INDEX idx_saved_search_matches_pending ON saved_search_matches(saved_search_id) WHERE notified_at IS NULL;
+
+-- When a product was last published or re-listed; the matcher scans by it
+ALTER TABLE products ADD COLUMN listed_at TIMESTAMP WITH TIME ZONE;
+
+-- Backfill without touching updated_at
+ALTER TABLE products DISABLE TRIGGER update_products_updated_at;
+UPDATE products SET listed_at = created_at WHERE status IN ('available', 'reserved');
+ALTER TABLE products ENABLE TRIGGER update_products_updated_at;
+
+CREATE INDEX idx_products_listed_at ON products(listed_at) WHERE status = 'available' AND deleted_at IS NULL;
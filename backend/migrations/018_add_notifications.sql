@@ -0,0 +1,20 @@
+-- Migration to add the notification center
+
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    link VARCHAR(500),
+    data JSONB,
+    read_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+-- Per-type channel choices; types not listed use the defaults
+ALTER TABLE users ADD COLUMN notification_preferences JSONB NOT NULL DEFAULT '{}';
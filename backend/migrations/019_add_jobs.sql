@@ -0,0 +1,31 @@
+-- Migration to add the background job queue
+
+CREATE TABLE jobs (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP WITH TIME ZONE,
+    locked_by VARCHAR(255) NOT NULL DEFAULT '',
+    last_error TEXT NOT NULL DEFAULT '',
+    completed_at TIMESTAMP WITH TIME ZONE,
+    unique_key VARCHAR(255) UNIQUE
+);
+
+-- Runners claim the oldest due pending job
+CREATE INDEX idx_jobs_pending ON jobs(run_at, id) WHERE status = 'pending';
+CREATE INDEX idx_jobs_running ON jobs(locked_at) WHERE status = 'running';
+CREATE INDEX idx_jobs_completed_at ON jobs(completed_at) WHERE status = 'done';
+
+CREATE TRIGGER update_jobs_updated_at BEFORE UPDATE ON jobs
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+-- Variants are now generated by a job after the upload
+ALTER TABLE uploads ADD COLUMN processed_at TIMESTAMP WITH TIME ZONE;
+UPDATE uploads SET processed_at = created_at;
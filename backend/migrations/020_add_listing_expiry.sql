@@ -0,0 +1,22 @@
+-- Migration to expire listings that are not renewed
+
+-- Categories can override LISTING_TTL with their own listing period
+ALTER TABLE categories ADD COLUMN listing_days INTEGER CHECK (listing_days > 0);
+
+ALTER TABLE products ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN renewal_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN expiry_warned_at TIMESTAMP WITH TIME ZONE;
+
+-- Existing listings get the default 30 days from when they were listed, and
+-- at least a week from now so sellers are warned before anything expires
+ALTER TABLE products DISABLE TRIGGER update_products_updated_at;
+
+UPDATE products SET expires_at = GREATEST(
+    COALESCE(listed_at, created_at) + INTERVAL '30 days',
+    NOW() + INTERVAL '7 days'
+)
+WHERE status IN ('available', 'reserved', 'hidden');
+
+ALTER TABLE products ENABLE TRIGGER update_products_updated_at;
+
+CREATE INDEX idx_products_expires_at ON products(expires_at) WHERE status = 'available';
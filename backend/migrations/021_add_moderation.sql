@@ -0,0 +1,34 @@
+-- Migration to add listing reports and the moderation queue
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_status_check;
+ALTER TABLE products ADD CONSTRAINT products_status_check
+    CHECK (status IN ('draft', 'pending_review', 'available', 'reserved', 'sold', 'hidden', 'expired', 'removed'));
+
+-- Set while a moderator has taken the listing down or removed it
+ALTER TABLE products ADD COLUMN moderated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN moderation_reason TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE reports (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(30) NOT NULL CHECK (reason IN ('spam', 'prohibited', 'fraud', 'offensive', 'counterfeit', 'wrong_category', 'duplicate', 'other')),
+    details TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'actioned', 'dismissed')),
+    resolved_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolution TEXT
+);
+
+-- Each user can report a listing once
+CREATE UNIQUE INDEX idx_reports_product_reporter ON reports(product_id, reporter_id);
+CREATE INDEX idx_reports_open ON reports(product_id) WHERE status = 'open';
+CREATE INDEX idx_reports_created_at ON reports(created_at);
+
+CREATE TRIGGER update_reports_updated_at BEFORE UPDATE ON reports
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX idx_products_pending_review ON products(created_at) WHERE status = 'pending_review';
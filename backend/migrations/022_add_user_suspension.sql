@@ -0,0 +1,8 @@
+-- Migration to record why admins suspended a user
+
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN suspension_reason TEXT NOT NULL DEFAULT '';
+
+-- Dashboard time series
+CREATE INDEX idx_users_created_at ON users(created_at);
+CREATE INDEX idx_products_sold_at ON products(sold_at) WHERE sold_at IS NOT NULL;
@@ -0,0 +1,32 @@
+-- Migration to replace the admins table with role-based users
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('user', 'support', 'moderator', 'admin'));
+
+-- Move the admins across as admin users. Their passwords are not carried
+-- over: the admins table was never used for sign-in, so its hashes were never
+-- meant to guard a working account. The '!' prefix makes the hash unusable,
+-- and migrated admins choose a password through a password reset.
+--
+-- The admin seeded by 001_initial_schema.sql is skipped while it still has
+-- its published password, and an admin whose email already belongs to a user
+-- is not merged: nothing proves the user owns the admin account, so promote
+-- them by hand if they do.
+INSERT INTO users (created_at, updated_at, email, password, first_name, last_name, is_verified, is_active, role)
+SELECT
+    created_at,
+    updated_at,
+    email,
+    '!' || password,
+    split_part(name, ' ', 1),
+    substr(name, length(split_part(name, ' ', 1)) + 2),
+    TRUE,
+    COALESCE(is_active, TRUE),
+    'admin'
+FROM admins
+WHERE deleted_at IS NULL
+    AND NOT (email = 'admin@bechdo.com' AND password = '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi')
+ON CONFLICT (email) DO NOTHING;
+
+DROP TABLE admins;
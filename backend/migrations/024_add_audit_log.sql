@@ -0,0 +1,40 @@
+-- Migration to add the append-only audit log
+
+-- actor_id and target_id carry no foreign keys: entries must outlive the rows
+-- they mention, and ON DELETE SET NULL would rewrite them
+CREATE TABLE audit_entries (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    actor_id INTEGER,
+    actor_email VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(30) NOT NULL DEFAULT '',
+    target_id INTEGER,
+    changes JSONB,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+
+    -- Each entry hashes its content together with the previous entry's hash
+    prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_entries_created_at ON audit_entries(created_at);
+CREATE INDEX idx_audit_entries_actor_id ON audit_entries(actor_id);
+CREATE INDEX idx_audit_entries_action ON audit_entries(action);
+CREATE INDEX idx_audit_entries_target ON audit_entries(target_type, target_id);
+
+-- Reject any change to recorded entries
+CREATE OR REPLACE FUNCTION reject_audit_entry_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit entries are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_entries_append_only BEFORE UPDATE OR DELETE ON audit_entries
+    FOR EACH ROW EXECUTE PROCEDURE reject_audit_entry_change();
+
+CREATE TRIGGER audit_entries_no_truncate BEFORE TRUNCATE ON audit_entries
+    FOR EACH STATEMENT EXECUTE PROCEDURE reject_audit_entry_change();
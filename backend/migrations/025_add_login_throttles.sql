@@ -0,0 +1,23 @@
+-- Migration to track failed sign-ins per email and per IP
+
+CREATE TABLE login_throttles (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('email', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Sign-ins are refused until then, after a delay or a lockout
+    blocked_until TIMESTAMP WITH TIME ZONE,
+    -- Set while locked out rather than just delayed
+    locked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_login_throttles_kind_key ON login_throttles(kind, key);
+CREATE INDEX idx_login_throttles_blocked_until ON login_throttles(blocked_until) WHERE blocked_until IS NOT NULL;
+CREATE INDEX idx_login_throttles_last_failure_at ON login_throttles(last_failure_at);
+
+CREATE TRIGGER update_login_throttles_updated_at BEFORE UPDATE ON login_throttles
+    FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
@@ -0,0 +1,9 @@
+-- Migration to remove sign-in links from finished email jobs
+
+-- Password reset and verification emails used to be queued with their full
+-- body, link included. They are now queued by user ID and the link is made
+-- when the email is sent; blank the bodies of the ones already sent or given up on
+UPDATE jobs SET payload = '{}'
+WHERE kind = 'email.send'
+    AND status IN ('done', 'dead')
+    AND (payload LIKE '%/reset-password?token=%' OR payload LIKE '%/verify-email?token=%');
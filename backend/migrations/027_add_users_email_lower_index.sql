@@ -0,0 +1,13 @@
+-- Migration to make emails unique regardless of case
+
+-- Accounts whose emails differ only in case could not all sign in; the
+-- oldest keeps the address and the others get an undeliverable one, so
+-- that their data is kept while support sorts them out
+UPDATE users SET email = users.email || '.duplicate-' || users.id || '.invalid'
+WHERE EXISTS (
+    SELECT 1 FROM users older
+    WHERE LOWER(older.email) = LOWER(users.email) AND older.id < users.id
+);
+
+-- Sign-up, sign-in and password resets look accounts up by LOWER(email)
+CREATE UNIQUE INDEX idx_users_email_lower ON users(LOWER(email));
@@ -0,0 +1,5 @@
+-- Migration to make the saved search watermark a (listed_at, id) cursor
+
+-- Listings are matched in (listed_at, id) order after
+-- (last_matched_at, last_matched_id)
+ALTER TABLE saved_searches ADD COLUMN last_matched_id INTEGER NOT NULL DEFAULT 0;
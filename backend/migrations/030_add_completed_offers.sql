@@ -0,0 +1,11 @@
+-- Migration to complete the accepted offers of sales that are over
+
+ALTER TABLE offers DROP CONSTRAINT IF EXISTS offers_status_check;
+ALTER TABLE offers ADD CONSTRAINT offers_status_check
+    CHECK (status IN ('pending', 'countered', 'accepted', 'declined', 'expired', 'cancelled', 'completed'));
+
+-- An accepted offer outlived its sale when the listing was removed, and
+-- would keep a restored listing from accepting another
+UPDATE offers SET status = 'completed'
+WHERE status = 'accepted'
+  AND product_id IN (SELECT id FROM products WHERE status NOT IN ('reserved', 'sold'));
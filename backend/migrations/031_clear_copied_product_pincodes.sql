@@ -0,0 +1,14 @@
+-- Migration to forget the locations copied from sellers onto listings
+
+-- 009 gave existing listings their seller's pincode, and the server then
+-- placed them at the seller's home. Listings still carrying their seller's
+-- pincode lose it and their coordinates; sellers can set a pincode for the
+-- listing itself.
+ALTER TABLE products DISABLE TRIGGER update_products_updated_at;
+
+UPDATE products p
+SET pin_code = NULL, latitude = NULL, longitude = NULL
+FROM users u
+WHERE p.user_id = u.id AND p.pin_code = u.pin_code;
+
+ALTER TABLE products ENABLE TRIGGER update_products_updated_at;
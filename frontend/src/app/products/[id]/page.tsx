@@ -301,17 +301,21 @@ export default function ProductDetailsPage() {
                     </div>
                     <div>
                       <h4 className="font-semibold">
-                        {product.user?.firstName} {product.user?.lastName}
+                        {product.user?.displayName}
                       </h4>
                       <p className="text-sm text-muted-foreground">
-                        Verified Seller
+                        {product.user?.isVerified ? "Verified Seller" : "Seller"}
                       </p>
                     </div>
                   </div>
-                  <div className="flex items-center">
-                    <Star className="h-4 w-4 text-yellow-400 mr-1" />
-                    <span className="font-semibold">4.8</span>
-                  </div>
+                  {!!product.user?.reviewCount && (
+                    <div className="flex items-center">
+                      <Star className="h-4 w-4 text-yellow-400 mr-1" />
+                      <span className="font-semibold">
+                        {product.user.rating.toFixed(1)}
+                      </span>
+                    </div>
+                  )}
                 </div>
 
                 {!isOwner && (
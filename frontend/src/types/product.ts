@@ -14,13 +14,7 @@ export interface Product {
   createdAt: string;
   updatedAt: string;
   soldAt?: string;
-  user?: {
-    id: number;
-    username: string;
-    firstName: string;
-    lastName: string;
-    profileImage?: string;
-  };
+  user?: PublicUser;
   category?: Category;
 }
 
@@ -70,3 +64,15 @@ export interface ProductsResponse {
     hasPrev: boolean;
   };
 }
+
+// Public view of a seller; contact details are never included
+export interface PublicUser {
+  id: number;
+  displayName: string;
+  city?: string;
+  memberSince: string;
+  isVerified: boolean;
+  rating: number;
+  reviewCount: number;
+  activeListings: number;
+}